@@ -0,0 +1,72 @@
+/*
+ * Created on 18/10/26 05.18
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package config
+
+import (
+	"bumn-sembako-be/helper"
+	"bumn-sembako-be/model"
+	"bumn-sembako-be/validator"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// migrateActiveNIK makes the database reject two active participants with the same NIK. MySQL has no partial
+// index so a generated column holds the NIK only while the row is not soft deleted, the unique index then
+// ignores its NULLs. Existing duplicates make the migration fail, it is retried on the next start
+func migrateActiveNIK(db *gorm.DB) {
+	if db.Migrator().HasColumn(&model.Participant{}, "active_nik") {
+		return
+	}
+
+	err := db.Exec("ALTER TABLE participants " +
+		"ADD COLUMN active_nik VARCHAR(255) GENERATED ALWAYS AS (IF(deleted_at IS NULL, NULLIF(nik, ''), NULL)) STORED, " +
+		"ADD UNIQUE INDEX idx_participants_active_nik (active_nik)").Error
+	if err != nil {
+		helper.CommonLogger().Error("Cannot add unique active NIK, remove duplicate participants first: ", err.Error()+"\n")
+	}
+}
+
+const MIGRATION_BIRTH_DATE = "participant_birth_date"
+
+// migrateBirthDate fills the birth date of the participants stored before it was derived from the NIK,
+// a NIK that can not be parsed keeps an empty birth date. It runs once, a failed run is retried on the next start
+func migrateBirthDate(db *gorm.DB) {
+	var done int64
+	err := db.Model(&model.Migration{}).Where("name = ?", MIGRATION_BIRTH_DATE).Count(&done).Error
+	if err != nil {
+		helper.CommonLogger().Error("Cannot read migrations: ", err.Error()+"\n")
+		return
+	}
+	if done > 0 {
+		return
+	}
+
+	var participants []*model.Participant
+	today := time.Now()
+	err = db.Select("id", "nik").Where("birth_date IS NULL").FindInBatches(&participants, 1000, func(tx *gorm.DB, batch int) error {
+		for _, participant := range participants {
+			parsed, err := validator.ParseNIK(participant.NIK, today)
+			if err != nil {
+				continue
+			}
+
+			err = tx.Session(&gorm.Session{NewDB: true}).Model(&model.Participant{}).Where("id = ?", participant.ID).
+				Update("birth_date", parsed.BirthDate).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+	if err == nil {
+		err = db.Create(&model.Migration{Name: MIGRATION_BIRTH_DATE}).Error
+	}
+	if err != nil {
+		helper.CommonLogger().Error("Cannot fill participant birth date: ", err.Error()+"\n")
+	}
+}
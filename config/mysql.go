@@ -32,8 +32,19 @@ func DbConnect(DBUSER, DBPASSWORD, DBHOST, DBPORT, DBNAME string) *gorm.DB {
 		model.Village{},
 		model.Quota{},
 		model.ImportLog{},
+		model.Session{},
+		model.PasswordHistory{},
+		model.ParticipantAudit{},
+		model.ParticipantStatusEvent{},
+		model.ImportMapping{},
+		model.ImportError{},
+		model.ValidationRule{},
+		model.Migration{},
 	)
 
+	migrateActiveNIK(db)
+	migrateBirthDate(db)
+
 	sqlDB, err := db.DB()
 	// SetMaxIdleConns sets the maximum number of connections in the idle connection pool.
 	sqlDB.SetMaxIdleConns(10)
@@ -8,9 +8,13 @@ package participant
 
 import (
 	"bumn-sembako-be/helper"
+	"bumn-sembako-be/middleware"
 	"bumn-sembako-be/request"
 	"bumn-sembako-be/usecase/participant"
+	userUsecase "bumn-sembako-be/usecase/user"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -36,8 +40,26 @@ type Handler interface {
 	ExportCSVData(c *gin.Context)
 	ImageHandler(c *gin.Context)
 	ImageBase64Handler(c *gin.Context)
+	ViewUpload(c *gin.Context)
 	Reset(c *gin.Context)
 	Delete(c *gin.Context)
+	ViewHistory(c *gin.Context)
+	ViewStatusEvents(c *gin.Context)
+	ViewImport(c *gin.Context)
+	ViewImportParticipants(c *gin.Context)
+	ViewImportErrors(c *gin.Context)
+	ViewImportErrorSummary(c *gin.Context)
+	RollbackImport(c *gin.Context)
+	ViewMappings(c *gin.Context)
+	CreateMapping(c *gin.Context)
+	UpdateMapping(c *gin.Context)
+	DeleteMapping(c *gin.Context)
+	ViewAgeGroups(c *gin.Context)
+	ViewValidationRules(c *gin.Context)
+	ViewEffectiveValidationRules(c *gin.Context)
+	CreateValidationRule(c *gin.Context)
+	UpdateValidationRule(c *gin.Context)
+	DeleteValidationRule(c *gin.Context)
 }
 
 type handler struct {
@@ -60,14 +82,14 @@ func (h *handler) ViewParticipants(c *gin.Context) {
 		return
 	}
 
-	participants, err := h.usecase.ReadAllBy(req)
+	participants, err := h.usecase.ReadAllBy(req, middleware.AuthUser(c))
 	if err != nil {
 		helper.CommonLogger().Error(err)
 		helper.HandleError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	countParticipants := h.usecase.Count(req)
+	countParticipants := h.usecase.Count(req, middleware.AuthUser(c))
 
 	helper.HandlePagedSuccess(c, participants, req.Page, req.Size, countParticipants)
 
@@ -106,7 +128,7 @@ func (h *handler) ViewParticipant(c *gin.Context) {
 		return
 	}
 
-	u, err := h.usecase.ReadById(id)
+	u, err := h.usecase.ReadById(id, middleware.AuthUser(c))
 	if err != nil {
 		helper.CommonLogger().Error(err)
 		helper.HandleError(c, http.StatusNotFound, err.Error())
@@ -134,6 +156,8 @@ func (h *handler) Update(c *gin.Context) {
 		return
 	}
 
+	tempParticipant.UpdatedBy = middleware.AuthUser(c).Username
+
 	if tempParticipant.Status != "PARTIAL_DONE" && tempParticipant.Status != "" && tempParticipant.File != nil {
 
 		path := "./uploads"
@@ -182,10 +206,10 @@ func (h *handler) Update(c *gin.Context) {
 
 	}
 
-	updatedParticipant, err := h.usecase.Update(id, tempParticipant)
+	updatedParticipant, err := h.usecase.Update(id, tempParticipant, middleware.AuthUser(c), c.ClientIP())
 	if err != nil {
 		helper.CommonLogger().Error(err)
-		helper.HandleError(c, http.StatusInternalServerError, err.Error())
+		helper.HandleError(c, statusErrorCode(err, http.StatusInternalServerError), err.Error())
 		return
 	}
 	helper.HandleSuccess(c, updatedParticipant)
@@ -209,6 +233,8 @@ func (h *handler) Edit(c *gin.Context) {
 		return
 	}
 
+	tempParticipant.UpdatedBy = middleware.AuthUser(c).Username
+
 	if tempParticipant.File != nil {
 		path := "./uploads"
 		if _, err := os.Stat(path); os.IsNotExist(err) {
@@ -253,10 +279,10 @@ func (h *handler) Edit(c *gin.Context) {
 		tempParticipant.ImagePenerima = "image/" + filename
 	}
 
-	updatedParticipant, err := h.usecase.Edit(id, tempParticipant)
+	updatedParticipant, err := h.usecase.Edit(id, tempParticipant, middleware.AuthUser(c), c.ClientIP())
 	if err != nil {
 		helper.CommonLogger().Error(err)
-		helper.HandleError(c, http.StatusInternalServerError, err.Error())
+		helper.HandleError(c, statusErrorCode(err, http.StatusInternalServerError), err.Error())
 		return
 	}
 	helper.HandleSuccess(c, updatedParticipant)
@@ -274,7 +300,7 @@ func (h *handler) ViewDashboard(c *gin.Context) {
 		return
 	}
 
-	result, err := h.usecase.GetTotalDashboardV2(req)
+	result, err := h.usecase.GetTotalDashboardV2(req, middleware.AuthUser(c))
 	if err != nil {
 		helper.CommonLogger().Error(err)
 		helper.HandleError(c, http.StatusNotFound, err.Error())
@@ -296,7 +322,7 @@ func (h *handler) ExportReport(c *gin.Context) {
 
 	req.Url = fmt.Sprintf("http://%s", c.Request.Host)
 
-	path, err := h.usecase.Export(req)
+	path, err := h.usecase.Export(req, middleware.AuthUser(c))
 	if err != nil {
 		helper.CommonLogger().Error(err)
 		helper.HandleError(c, http.StatusInternalServerError, err.Error())
@@ -350,7 +376,7 @@ func (h *handler) ExportReportV2(c *gin.Context) {
 
 	req.Url = fmt.Sprintf("http://%s", c.Request.Host)
 
-	path, err := h.usecase.ExportV2(req)
+	path, err := h.usecase.ExportV2(req, middleware.AuthUser(c))
 	if err != nil {
 		helper.CommonLogger().Error(err)
 		helper.HandleError(c, http.StatusInternalServerError, err.Error())
@@ -394,43 +420,445 @@ func (h *handler) ExportReportV2(c *gin.Context) {
 func (h *handler) BulkCreate(c *gin.Context) {
 	var req request.ImportParticipant
 	var err error
-	err = c.ShouldBind(&req)
+
+	path := "./uploads"
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		_ = os.Mkdir(path, os.ModePerm)
+	}
+
+	currentTime := time.Now()
+
+	// a json array can be posted as the body itself, the import options are then read from the query
+	if c.ContentType() == "application/json" {
+		err = c.ShouldBindQuery(&req)
+		if err != nil {
+			helper.CommonLogger().Error(err)
+			helper.HandleError(c, http.StatusInternalServerError, "Oopss server someting wrong")
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			helper.CommonLogger().Error(err)
+			helper.HandleError(c, http.StatusBadRequest, "failed to read body")
+			return
+		}
+
+		token, err := helper.RandomToken(8)
+		if err != nil {
+			helper.CommonLogger().Error(err)
+			helper.HandleError(c, http.StatusInternalServerError, "Oopss server someting wrong")
+			return
+		}
+
+		filename := currentTime.Format("20060102150405") + "-" + token + ".json"
+		tmpFile := path + "/" + filename
+		if err = os.WriteFile(tmpFile, body, 0644); err != nil {
+			helper.HandleError(c, http.StatusBadRequest, "failed to saving file")
+			return
+		}
+
+		req.Name = filename
+		req.TmpPath = tmpFile
+	} else {
+		err = c.ShouldBind(&req)
+		if err != nil {
+			helper.CommonLogger().Error(err)
+			helper.HandleError(c, http.StatusInternalServerError, "Oopss server someting wrong")
+			return
+		}
+
+		file := req.File
+		if file == nil {
+			helper.HandleError(c, http.StatusBadRequest, "file wajib diunggah")
+			return
+		}
+
+		// generate new file name, the token keeps uploads of the same second apart
+		token, err := helper.RandomToken(8)
+		if err != nil {
+			helper.CommonLogger().Error(err)
+			helper.HandleError(c, http.StatusInternalServerError, "Oopss server someting wrong")
+			return
+		}
+
+		ext := filepath.Ext(file.Filename)
+		filename := currentTime.Format("20060102150405") + "-" + token + ext
+
+		tmpFile := path + "/" + filename
+		if err = c.SaveUploadedFile(file, tmpFile); err != nil {
+			helper.HandleError(c, http.StatusBadRequest, "failed to saving image")
+			return
+		}
+
+		req.Name = file.Filename
+		req.TmpPath = tmpFile
+	}
+
+	req.UploadedBy = middleware.AuthUser(c).Username
+	req.UploadedByID = middleware.AuthUser(c).ID
+	req.OrganizationID = participant.MappingOrganization(middleware.AuthUser(c))
+	req.IP = c.ClientIP()
+
+	if req.DryRun {
+		dryRun, err := h.usecase.DryRunImport(req, middleware.AuthUser(c))
+		if err != nil {
+			helper.CommonLogger().Error(err)
+			helper.HandleError(c, importErrorCode(err, http.StatusInternalServerError), err.Error())
+			return
+		}
+
+		helper.HandleSuccess(c, dryRun)
+		return
+	}
+
+	result, err := h.usecase.BulkCreate(req, middleware.AuthUser(c))
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, importErrorCode(err, http.StatusInternalServerError), err.Error())
+		return
+	}
+
+	helper.HandleSuccess(c, result)
+
+}
+
+func (h *handler) ViewImport(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusBadRequest, "id has be number")
+		return
+	}
+
+	importLog, err := h.usecase.ReadLog(id, middleware.AuthUser(c))
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, importErrorCode(err, http.StatusNotFound), err.Error())
+		return
+	}
+
+	helper.HandleSuccess(c, importLog)
+}
+
+func (h *handler) ViewImportParticipants(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusBadRequest, "id has be number")
+		return
+	}
+
+	var req request.ParticipantPaged
+	err = c.ShouldBindQuery(&req)
 	if err != nil {
 		helper.CommonLogger().Error(err)
 		helper.HandleError(c, http.StatusInternalServerError, "Oopss server someting wrong")
 		return
 	}
 
-	path := "./uploads"
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		_ = os.Mkdir(path, os.ModePerm)
+	participants, err := h.usecase.ReadAllImported(id, req, middleware.AuthUser(c))
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, importErrorCode(err, http.StatusNotFound), err.Error())
+		return
 	}
 
-	file := req.File
+	countParticipants := h.usecase.CountImported(id, req, middleware.AuthUser(c))
 
-	// generate new file name
-	ext := filepath.Ext(file.Filename)
-	currentTime := time.Now()
-	filename := currentTime.Format("20060102150405") + ext
+	helper.HandlePagedSuccess(c, participants, req.Page, req.Size, countParticipants)
+}
 
-	tmpFile := path + "/" + filename
-	if err = c.SaveUploadedFile(file, tmpFile); err != nil {
-		helper.HandleError(c, http.StatusBadRequest, "failed to saving image")
+func (h *handler) ViewImportErrors(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusBadRequest, "id has be number")
 		return
 	}
 
-	req.Name = file.Filename
-	req.TmpPath = tmpFile
+	var req request.ImportErrorPaged
+	err = c.ShouldBindQuery(&req)
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusInternalServerError, "Oopss server someting wrong")
+		return
+	}
 
-	result, err := h.usecase.BulkCreate(req)
+	importErrors, err := h.usecase.ReadAllImportErrors(id, req, middleware.AuthUser(c))
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, importErrorCode(err, http.StatusNotFound), err.Error())
+		return
+	}
+
+	countImportErrors := h.usecase.CountImportErrors(id, req)
+
+	helper.HandlePagedSuccess(c, importErrors, req.Page, req.Size, countImportErrors)
+}
+
+func (h *handler) ViewImportErrorSummary(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusBadRequest, "id has be number")
+		return
+	}
+
+	counts, err := h.usecase.SummarizeImportErrors(id, middleware.AuthUser(c))
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, importErrorCode(err, http.StatusNotFound), err.Error())
+		return
+	}
+
+	helper.HandleSuccess(c, counts)
+}
+
+func (h *handler) RollbackImport(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusBadRequest, "id has be number")
+		return
+	}
+
+	importLog, err := h.usecase.RollbackImport(id, middleware.AuthUser(c), c.ClientIP())
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, importErrorCode(err, http.StatusInternalServerError), err.Error())
+		return
+	}
+
+	helper.HandleSuccess(c, importLog)
+}
+
+func (h *handler) ViewMappings(c *gin.Context) {
+	var req request.ImportMappingPaged
+	err := c.ShouldBindQuery(&req)
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusInternalServerError, "Oopss server someting wrong")
+		return
+	}
+
+	mappings, err := h.usecase.ReadAllMapping(req, middleware.AuthUser(c))
 	if err != nil {
 		helper.CommonLogger().Error(err)
 		helper.HandleError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	helper.HandleSuccess(c, mappings)
+}
+
+func (h *handler) CreateMapping(c *gin.Context) {
+	var req request.ImportMappingInput
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusBadRequest, "Oopss server someting wrong")
+		return
+	}
+
+	mapping, err := h.usecase.CreateMapping(req, middleware.AuthUser(c))
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.HandleSuccess(c, mapping)
+}
+
+func (h *handler) UpdateMapping(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusBadRequest, "id has be number")
+		return
+	}
+
+	var req request.ImportMappingInput
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusBadRequest, "Oopss server someting wrong")
+		return
+	}
+
+	mapping, err := h.usecase.UpdateMapping(id, req, middleware.AuthUser(c))
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.HandleSuccess(c, mapping)
+}
+
+func (h *handler) DeleteMapping(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusBadRequest, "id has be number")
+		return
+	}
+
+	err = h.usecase.DeleteMapping(id, middleware.AuthUser(c))
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	helper.HandleSuccess(c, "success delete data")
+}
+
+// ViewAgeGroups counts the participants in scope per age group of their birth date
+func (h *handler) ViewAgeGroups(c *gin.Context) {
+	var req request.ParticipantFilter
+	err := c.ShouldBindQuery(&req)
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusInternalServerError, "Oopss server someting wrong")
+		return
+	}
+
+	result, err := h.usecase.CountAgeGroups(req, middleware.AuthUser(c))
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
 	helper.HandleSuccess(c, result)
+}
+
+func (h *handler) ViewValidationRules(c *gin.Context) {
+	var req request.ValidationRulePaged
+	err := c.ShouldBindQuery(&req)
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusInternalServerError, "Oopss server someting wrong")
+		return
+	}
+
+	rules, err := h.usecase.ReadAllValidationRule(req)
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
 
+	helper.HandleSuccess(c, rules)
+}
+
+func (h *handler) ViewEffectiveValidationRules(c *gin.Context) {
+	var req request.ValidationRulePaged
+	err := c.ShouldBindQuery(&req)
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusInternalServerError, "Oopss server someting wrong")
+		return
+	}
+
+	rules, err := h.usecase.ReadEffectiveValidationRules(req.Type)
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	helper.HandleSuccess(c, rules)
+}
+
+func (h *handler) CreateValidationRule(c *gin.Context) {
+	var req request.ValidationRuleInput
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusBadRequest, "Oopss server someting wrong")
+		return
+	}
+
+	rule, err := h.usecase.CreateValidationRule(req)
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.HandleSuccess(c, rule)
+}
+
+func (h *handler) UpdateValidationRule(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusBadRequest, "id has be number")
+		return
+	}
+
+	var req request.ValidationRuleInput
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusBadRequest, "Oopss server someting wrong")
+		return
+	}
+
+	rule, err := h.usecase.UpdateValidationRule(id, req)
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.HandleSuccess(c, rule)
+}
+
+func (h *handler) DeleteValidationRule(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusBadRequest, "id has be number")
+		return
+	}
+
+	err = h.usecase.DeleteValidationRule(id)
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	helper.HandleSuccess(c, "success delete data")
+}
+
+// importErrorCode maps the import errors to their http status, other errors use the fallback
+func importErrorCode(err error, fallback int) int {
+	switch {
+	case errors.Is(err, participant.ErrImportNotFinished), errors.Is(err, participant.ErrImportRolledBack),
+		errors.Is(err, participant.ErrImportProcessed):
+		return http.StatusConflict
+	case errors.Is(err, participant.ErrImportNoReference), errors.Is(err, participant.ErrImportTooLarge):
+		return http.StatusBadRequest
+	case errors.Is(err, participant.ErrImportNotUploader), errors.Is(err, participant.ErrImportNotViewable):
+		return http.StatusForbidden
+	case errors.Is(err, participant.ErrImportQueueFull):
+		return http.StatusServiceUnavailable
+	}
+	return fallback
 }
 
 func (h *handler) ExportExcel(c *gin.Context) {
@@ -444,7 +872,7 @@ func (h *handler) ExportExcel(c *gin.Context) {
 		return
 	}
 
-	result, err := h.usecase.ExportExcel(req)
+	result, err := h.usecase.ExportExcel(req, middleware.AuthUser(c))
 	if err != nil {
 		helper.CommonLogger().Error(err)
 		helper.HandleError(c, http.StatusNotFound, err.Error())
@@ -465,7 +893,7 @@ func (h *handler) ExportExcelData(c *gin.Context) {
 		return
 	}
 
-	result, err := h.usecase.ExportExcelData(req)
+	result, err := h.usecase.ExportExcelData(req, middleware.AuthUser(c))
 	if err != nil {
 		helper.CommonLogger().Error(err)
 		helper.HandleError(c, http.StatusNotFound, err.Error())
@@ -486,7 +914,7 @@ func (h *handler) ExportCSVData(c *gin.Context) {
 		return
 	}
 
-	result, err := h.usecase.ExportCSVData(req)
+	result, err := h.usecase.ExportCSVData(req, middleware.AuthUser(c))
 	if err != nil {
 		helper.CommonLogger().Error(err)
 		helper.HandleError(c, http.StatusNotFound, err.Error())
@@ -520,6 +948,42 @@ func (h *handler) ImageBase64Handler(c *gin.Context) {
 	c.JSON(http.StatusOK, base64Image)
 }
 
+// uploadPermission is the permission needed to read a file of ./uploads, told apart by the suffix its
+// writer gave the name. Photos need participant:view, reports and exports need report:export and the rest
+// are import files and their results
+func uploadPermission(filename string) string {
+	switch {
+	case strings.Contains(filename, "-image-"):
+		return userUsecase.PERMISSION_PARTICIPANT_VIEW
+	case strings.HasSuffix(filename, "-report.pdf"), strings.Contains(filename, "-dashboard"),
+		strings.Contains(filename, "-export-data"):
+		return userUsecase.PERMISSION_REPORT_EXPORT
+	}
+	return userUsecase.PERMISSION_PARTICIPANT_IMPORT
+}
+
+func (h *handler) ViewUpload(c *gin.Context) {
+	filename := filepath.Base(c.Param("filename"))
+	if filename == "." || filename == "/" || strings.HasPrefix(filename, ".") {
+		helper.HandleError(c, http.StatusNotFound, "file tidak ditemukan")
+		return
+	}
+
+	authUser := middleware.AuthUser(c)
+	if authUser == nil || !userUsecase.HasPermission(authUser.Role, uploadPermission(filename)) {
+		helper.HandleError(c, http.StatusForbidden, "you don't have permission to access this resource")
+		return
+	}
+
+	path := filepath.Join("uploads", filename)
+	if info, err := os.Stat(path); err != nil || info.IsDir() {
+		helper.HandleError(c, http.StatusNotFound, "file tidak ditemukan")
+		return
+	}
+
+	c.File(path)
+}
+
 func (h *handler) Reset(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
@@ -529,15 +993,35 @@ func (h *handler) Reset(c *gin.Context) {
 		return
 	}
 
-	u, err := h.usecase.Reset(id)
+	var req request.ResetParticipant
+	err = c.ShouldBind(&req)
+	if err != nil && !errors.Is(err, io.EOF) {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusBadRequest, "Oopss server someting wrong")
+		return
+	}
+
+	u, err := h.usecase.Reset(id, req.Reason, middleware.AuthUser(c), c.ClientIP())
 	if err != nil {
 		helper.CommonLogger().Error(err)
-		helper.HandleError(c, http.StatusNotFound, err.Error())
+		helper.HandleError(c, statusErrorCode(err, http.StatusNotFound), err.Error())
 		return
 	}
 	helper.HandleSuccess(c, u)
 }
 
+// statusErrorCode maps status transition errors to their http status, other errors keep the fallback code
+func statusErrorCode(err error, fallback int) int {
+	switch {
+	case errors.Is(err, participant.ErrIllegalTransition):
+		return http.StatusConflict
+	case errors.Is(err, participant.ErrUnknownStatus), errors.Is(err, participant.ErrReasonRequired),
+		errors.Is(err, participant.ErrInvalidParticipant):
+		return http.StatusBadRequest
+	}
+	return fallback
+}
+
 func (h *handler) Delete(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
@@ -546,7 +1030,7 @@ func (h *handler) Delete(c *gin.Context) {
 		helper.HandleError(c, http.StatusBadRequest, "id has be number")
 		return
 	}
-	err = h.usecase.Delete(id)
+	err = h.usecase.Delete(id, middleware.AuthUser(c), c.ClientIP())
 	if err != nil {
 		helper.CommonLogger().Error(err)
 		helper.HandleError(c, http.StatusNotFound, err.Error())
@@ -554,3 +1038,51 @@ func (h *handler) Delete(c *gin.Context) {
 	}
 	helper.HandleSuccess(c, "success delete data")
 }
+
+func (h *handler) ViewHistory(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusBadRequest, "id has be number")
+		return
+	}
+
+	var req request.HistoryPaged
+	err = c.ShouldBindQuery(&req)
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusInternalServerError, "Oopss server someting wrong")
+		return
+	}
+
+	histories, err := h.usecase.ReadAllHistory(id, req, middleware.AuthUser(c))
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	countHistories := h.usecase.CountHistory(id)
+
+	helper.HandlePagedSuccess(c, histories, req.Page, req.Size, countHistories)
+}
+
+func (h *handler) ViewStatusEvents(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusBadRequest, "id has be number")
+		return
+	}
+
+	events, err := h.usecase.ReadAllStatusEvents(id, middleware.AuthUser(c))
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	helper.HandleSuccess(c, events)
+}
@@ -19,6 +19,7 @@ type Handler interface {
 	ViewRegenciesByProvinceId(c *gin.Context)
 	ViewDistrictsByRegencyId(c *gin.Context)
 	ViewVillagesByDistrictId(c *gin.Context)
+	RefreshCache(c *gin.Context)
 }
 
 type handler struct {
@@ -115,3 +116,14 @@ func (h *handler) ViewVillagesByDistrictId(c *gin.Context) {
 	helper.HandleSuccess(c, villages)
 
 }
+
+func (h *handler) RefreshCache(c *gin.Context) {
+	err := h.usecase.RefreshCache()
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	helper.HandleSuccess(c, "region cache refreshed")
+}
@@ -8,9 +8,11 @@ package user
 
 import (
 	"bumn-sembako-be/helper"
+	"bumn-sembako-be/middleware"
 	"bumn-sembako-be/model"
 	"bumn-sembako-be/request"
 	"bumn-sembako-be/usecase/user"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"strconv"
@@ -20,13 +22,20 @@ type Handler interface {
 	Register(c *gin.Context)
 	RegisterYayasan(c *gin.Context)
 	Login(c *gin.Context)
+	Refresh(c *gin.Context)
+	Logout(c *gin.Context)
+	LogoutAll(c *gin.Context)
 	ViewUsers(c *gin.Context)
 	CreateUser(c *gin.Context)
 	UpdateUser(c *gin.Context)
 	DeleteUser(c *gin.Context)
+	UnlockUser(c *gin.Context)
+	ResetPassword(c *gin.Context)
+	ChangePassword(c *gin.Context)
 	ViewOrganizations(c *gin.Context)
 	ViewEOOrganizations(c *gin.Context)
 	ViewYayasanOrganizations(c *gin.Context)
+	ViewPermissions(c *gin.Context)
 }
 
 type handler struct {
@@ -92,45 +101,93 @@ func (h *handler) RegisterYayasan(c *gin.Context) {
 }
 
 func (h *handler) Login(c *gin.Context) {
-	var user = request.Login{}
-	err := c.Bind(&user)
+	var input = request.Login{}
+	err := c.Bind(&input)
 	if err != nil {
 		helper.CommonLogger().Error(err)
 		helper.HandleError(c, http.StatusInternalServerError, "Oopss server someting wrong")
 		return
 	}
 
-	dbUser, err := h.usecase.Login(user)
+	dbUser, err := h.usecase.Login(input, c.ClientIP())
+	if err != nil {
+		if errors.Is(err, user.ErrAccountLocked) {
+			helper.HandleError(c, http.StatusTooManyRequests, err.Error())
+			return
+		}
+
+		if errors.Is(err, user.ErrInvalidCredential) {
+			helper.HandleError(c, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	token, err := h.usecase.CreateSession(dbUser, c.Request.UserAgent(), c.ClientIP())
 	if err != nil {
 		helper.CommonLogger().Error(err)
 		helper.HandleError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	isTrue := helper.ComparePassword(dbUser.Password, user.Password)
-	if !isTrue {
+	result := map[string]interface{}{"token": token.Token, "expires_at": token.ExpiresAt, "refresh_token": token.RefreshToken, "refresh_expires_at": token.RefreshExpiresAt, "must_change_password": dbUser.MustChangePassword, "user": dbUser.Name, "userData": dbUser}
+	helper.HandleSuccess(c, result)
+
+}
+
+func (h *handler) Refresh(c *gin.Context) {
+	var input request.RefreshToken
+	err := c.Bind(&input)
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusInternalServerError, "Oopss server someting wrong")
+		return
+	}
+
+	if input.RefreshToken == "" {
+		helper.HandleError(c, http.StatusBadRequest, "column cannot be empty")
+		return
+	}
 
-		dbUser.RetryAttempts = dbUser.RetryAttempts + 1
-		if dbUser.RetryAttempts > 3 {
-			helper.HandleError(c, http.StatusTooManyRequests, "You have retry 3 times")
+	token, dbUser, err := h.usecase.Refresh(input.RefreshToken)
+	if err != nil {
+		if errors.Is(err, user.ErrSessionRevoked) {
+			helper.HandleError(c, http.StatusUnauthorized, err.Error())
 			return
 		}
-		_, err = h.usecase.Update(dbUser.ID, dbUser)
-		helper.HandleError(c, http.StatusInternalServerError, "Password not matched")
+
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	dbUser.RetryAttempts = 0
-	_, err = h.usecase.Update(dbUser.ID, dbUser)
+	result := map[string]interface{}{"token": token.Token, "expires_at": token.ExpiresAt, "refresh_token": token.RefreshToken, "refresh_expires_at": token.RefreshExpiresAt, "must_change_password": dbUser.MustChangePassword, "user": dbUser.Name, "userData": dbUser}
+	helper.HandleSuccess(c, result)
+
+}
+
+func (h *handler) Logout(c *gin.Context) {
+	err := h.usecase.Logout(middleware.AuthSession(c))
 	if err != nil {
 		helper.CommonLogger().Error(err)
 		helper.HandleError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
+	helper.HandleSuccess(c, "success logout")
 
-	token := helper.GenerateToken(dbUser)
-	result := map[string]interface{}{"token": token, "user": dbUser.Name, "userData": dbUser}
-	helper.HandleSuccess(c, result)
+}
+
+func (h *handler) LogoutAll(c *gin.Context) {
+	err := h.usecase.LogoutAll(middleware.AuthUser(c).ID)
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	helper.HandleSuccess(c, "success logout from all devices")
 
 }
 
@@ -172,6 +229,11 @@ func (h *handler) CreateUser(c *gin.Context) {
 		return
 	}
 
+	if !h.canAssignRole(c, user.Role) {
+		helper.HandleError(c, http.StatusForbidden, "you don't have permission to assign this role")
+		return
+	}
+
 	newUser, err := h.usecase.Create(user)
 	if err != nil {
 		helper.CommonLogger().Error(err)
@@ -210,6 +272,16 @@ func (h *handler) UpdateUser(c *gin.Context) {
 		return
 	}
 
+	if !h.canManage(c, existUser) {
+		helper.HandleError(c, http.StatusForbidden, "you don't have permission to manage this user")
+		return
+	}
+
+	if tempUser.Role != "" && tempUser.Role != existUser.Role && !h.canAssignRole(c, tempUser.Role) {
+		helper.HandleError(c, http.StatusForbidden, "you don't have permission to assign this role")
+		return
+	}
+
 	if tempUser.Password == "" {
 		tempUser.Password = existUser.Password
 	}
@@ -231,6 +303,18 @@ func (h *handler) DeleteUser(c *gin.Context) {
 		helper.HandleError(c, http.StatusBadRequest, "id has be number")
 		return
 	}
+	existUser, err := h.usecase.ReadById(id)
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	if !h.canManage(c, existUser) {
+		helper.HandleError(c, http.StatusForbidden, "you don't have permission to manage this user")
+		return
+	}
+
 	err = h.usecase.Delete(id)
 	if err != nil {
 		helper.CommonLogger().Error(err)
@@ -241,6 +325,92 @@ func (h *handler) DeleteUser(c *gin.Context) {
 
 }
 
+func (h *handler) UnlockUser(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusBadRequest, "id has be number")
+		return
+	}
+	existUser, err := h.usecase.ReadById(id)
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	if !h.canManage(c, existUser) {
+		helper.HandleError(c, http.StatusForbidden, "you don't have permission to manage this user")
+		return
+	}
+
+	u, err := h.usecase.Unlock(id)
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	helper.HandleSuccess(c, u)
+
+}
+
+func (h *handler) ResetPassword(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusBadRequest, "id has be number")
+		return
+	}
+	existUser, err := h.usecase.ReadById(id)
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	if !h.canManage(c, existUser) {
+		helper.HandleError(c, http.StatusForbidden, "you don't have permission to manage this user")
+		return
+	}
+
+	temporaryPassword, err := h.usecase.ResetPassword(id)
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	result := map[string]interface{}{"username": existUser.Username, "temporary_password": temporaryPassword}
+	helper.HandleSuccess(c, result)
+
+}
+
+func (h *handler) ChangePassword(c *gin.Context) {
+	var input request.UpdateUser
+	err := c.Bind(&input)
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusInternalServerError, "Oopss server someting wrong")
+		return
+	}
+
+	if input.OldPassword == "" || input.Password == "" {
+		helper.HandleError(c, http.StatusBadRequest, "column cannot be empty")
+		return
+	}
+
+	err = h.usecase.ChangePassword(middleware.AuthUser(c).ID, middleware.AuthSession(c), input)
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		helper.HandleError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	helper.HandleSuccess(c, "success change password")
+
+}
+
 func (h *handler) ViewOrganizations(c *gin.Context) {
 	organizations, err := h.usecase.ReadAllOrganization()
 	if err != nil {
@@ -276,3 +446,23 @@ func (h *handler) ViewYayasanOrganizations(c *gin.Context) {
 	helper.HandleSuccess(c, organizations)
 
 }
+
+func (h *handler) ViewPermissions(c *gin.Context) {
+	authUser := middleware.AuthUser(c)
+
+	result := map[string]interface{}{"role": authUser.Role, "permissions": user.Permissions(authUser.Role)}
+	helper.HandleSuccess(c, result)
+
+}
+
+func (h *handler) canAssignRole(c *gin.Context, role string) bool {
+	return user.CanAssignRole(middleware.AuthUser(c).Role, role)
+}
+
+// canManage prevents an ADMIN from changing or removing administrator accounts
+func (h *handler) canManage(c *gin.Context, target *model.User) bool {
+	if user.IsAdmin(target.Role) {
+		return middleware.AuthUser(c).Role == user.ROLE_SUPERADMIN
+	}
+	return true
+}
@@ -0,0 +1,15 @@
+package helper
+
+import (
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+const mysqlDuplicateEntry = 1062
+
+// IsDuplicateKey reports whether the query failed on a unique index
+func IsDuplicateKey(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry
+}
@@ -0,0 +1,170 @@
+/*
+ * Created on 18/10/26 14.10
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package helper
+
+import (
+	"bumn-sembako-be/model"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/spf13/viper"
+)
+
+// Claims -> compact claims, the user itself is always loaded fresh from the database
+type Claims struct {
+	UserID         int    `json:"uid"`
+	Role           string `json:"role"`
+	OrganizationID uint   `json:"oid,omitempty"`
+	Provinsi       string `json:"prov,omitempty"`
+	Kota           string `json:"kota,omitempty"`
+	SessionID      int    `json:"sid"`
+	jwt.StandardClaims
+}
+
+type keyRing struct {
+	mu     sync.RWMutex
+	active string
+	keys   map[string][]byte
+}
+
+var jwtKeys = &keyRing{keys: map[string][]byte{}}
+
+// LoadKeyRing -> loads the signing keys from JWT_KEYS ("kid:secret,kid:secret") and signs with JWT_ACTIVE_KEY.
+// Old keys stay in JWT_KEYS until the tokens signed with them expire. JWT_SECRET alone is still accepted as kid "default".
+func LoadKeyRing() error {
+	keys := map[string][]byte{}
+	active := strings.TrimSpace(viper.GetString("JWT_ACTIVE_KEY"))
+
+	for _, pair := range strings.Split(viper.GetString("JWT_KEYS"), ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		kid, secret, found := strings.Cut(pair, ":")
+		if !found || kid == "" || secret == "" {
+			return fmt.Errorf("invalid JWT_KEYS entry %q", kid)
+		}
+		keys[kid] = []byte(secret)
+	}
+
+	if secret := viper.GetString("JWT_SECRET"); secret != "" {
+		if _, exists := keys["default"]; !exists {
+			keys["default"] = []byte(secret)
+		}
+		if active == "" && len(keys) == 1 {
+			active = "default"
+		}
+	}
+
+	if len(keys) == 0 {
+		return fmt.Errorf("no jwt key configured, set JWT_KEYS and JWT_ACTIVE_KEY")
+	}
+
+	if _, ok := keys[active]; !ok {
+		return fmt.Errorf("JWT_ACTIVE_KEY %q is not in JWT_KEYS", active)
+	}
+
+	jwtKeys.mu.Lock()
+	defer jwtKeys.mu.Unlock()
+	jwtKeys.active = active
+	jwtKeys.keys = keys
+
+	return nil
+}
+
+func (r *keyRing) signingKey() (string, []byte) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.active, r.keys[r.active]
+}
+
+func (r *keyRing) key(kid string) ([]byte, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	key, ok := r.keys[kid]
+	return key, ok
+}
+
+// HashToken -> hashes refresh tokens before they are stored
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// AccessTokenTTL -> lifetime of the access token, ACCESS_TOKEN_MINUTES in .env
+func AccessTokenTTL() time.Duration {
+	if v := viper.GetInt("ACCESS_TOKEN_MINUTES"); v > 0 {
+		return time.Duration(v) * time.Minute
+	}
+	return 30 * time.Minute
+}
+
+// RefreshTokenTTL -> lifetime of the refresh token, REFRESH_TOKEN_DAYS in .env
+func RefreshTokenTTL() time.Duration {
+	if v := viper.GetInt("REFRESH_TOKEN_DAYS"); v > 0 {
+		return time.Duration(v) * 24 * time.Hour
+	}
+	return 7 * 24 * time.Hour
+}
+
+// GenerateToken -> generates token bound to the session
+func GenerateToken(user *model.User, sessionID int) (string, time.Time) {
+	now := time.Now()
+	expiresAt := now.Add(AccessTokenTTL())
+
+	claims := Claims{
+		UserID:         user.ID,
+		Role:           user.Role,
+		OrganizationID: user.OrganizationID,
+		Provinsi:       user.Provinsi,
+		Kota:           user.Kota,
+		SessionID:      sessionID,
+		StandardClaims: jwt.StandardClaims{
+			Subject:   strconv.Itoa(user.ID),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: expiresAt.Unix(),
+		},
+	}
+
+	kid, key := jwtKeys.signingKey()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = kid
+	t, _ := token.SignedString(key)
+	return t, expiresAt
+
+}
+
+// ValidateToken --> validate the given token with the key named in its kid header
+func ValidateToken(token string) (*Claims, error) {
+	claims := &Claims{}
+
+	_, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			//nil secret key
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+
+		kid, _ := token.Header["kid"].(string)
+		key, ok := jwtKeys.key(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key: %v", token.Header["kid"])
+		}
+		return key, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
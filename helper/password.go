@@ -0,0 +1,53 @@
+/*
+ * Created on 18/10/26 05.05
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package helper
+
+import (
+	"fmt"
+	"unicode"
+)
+
+const passwordMinLength = 8
+
+// ValidatePassword enforces the password policy: at least 8 characters with upper case, lower case and a digit
+func ValidatePassword(pass string) error {
+	var hasUpper, hasLower, hasDigit bool
+	for _, char := range pass {
+		switch {
+		case unicode.IsUpper(char):
+			hasUpper = true
+		case unicode.IsLower(char):
+			hasLower = true
+		case unicode.IsDigit(char):
+			hasDigit = true
+		}
+	}
+
+	if len(pass) < passwordMinLength {
+		return fmt.Errorf("password minimal %d karakter", passwordMinLength)
+	}
+
+	if !hasUpper || !hasLower || !hasDigit {
+		return fmt.Errorf("password harus mengandung huruf besar, huruf kecil dan angka")
+	}
+
+	return nil
+}
+
+// TemporaryPassword generates a one-time password that satisfies the password policy
+func TemporaryPassword() (string, error) {
+	for {
+		pass, err := Randstring(12)
+		if err != nil {
+			return "", err
+		}
+
+		if ValidatePassword(pass) == nil {
+			return pass, nil
+		}
+	}
+}
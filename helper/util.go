@@ -2,6 +2,7 @@ package helper
 
 import (
 	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"strings"
 )
@@ -21,6 +22,15 @@ func Randstring(n int) (string, error) {
 	return string(s), nil
 }
 
+// RandomToken returns n random bytes hex encoded, used for refresh tokens
+func RandomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("random token n %d: %w", n, err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
 func ContainString(alpha, value string) bool {
 	for _, char := range value {
 		if !strings.Contains(alpha, strings.ToLower(string(char))) {
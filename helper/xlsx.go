@@ -0,0 +1,587 @@
+/*
+ * Created on 18/10/26 05.30
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package helper
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const xlsxRelationshipNS = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+
+// xlsxDateLayout is how a date cell is returned, with the time when the cell has one
+const xlsxDateLayout = "2006-01-02"
+const xlsxDateTimeLayout = "2006-01-02 15:04:05"
+
+// XLSXReader streams the rows of one sheet, only the shared strings and the styles of the workbook are kept
+// in memory. Cells are returned as text: a date as yyyy-mm-dd, a boolean as TRUE or FALSE and a number
+// without an exponent
+type XLSXReader struct {
+	archive    *zip.ReadCloser
+	sheet      io.ReadCloser
+	decoder    *xml.Decoder
+	shared     []string
+	dateStyles []bool
+	date1904   bool
+	line       int
+}
+
+type xlsxWorkbook struct {
+	Properties struct {
+		Date1904 bool `xml:"date1904,attr"`
+	} `xml:"workbookPr"`
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Type   string `xml:"Type,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxStyles struct {
+	NumFmts []struct {
+		ID   int    `xml:"numFmtId,attr"`
+		Code string `xml:"formatCode,attr"`
+	} `xml:"numFmts>numFmt"`
+	CellXfs []struct {
+		NumFmtID int `xml:"numFmtId,attr"`
+	} `xml:"cellXfs>xf"`
+}
+
+// OpenXLSX opens the sheet named by sheet, a number is the position of the sheet starting at 1
+// and an empty sheet is the first sheet of the workbook
+func OpenXLSX(filename, sheet string) (*XLSXReader, error) {
+	archive, err := zip.OpenReader(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := &XLSXReader{archive: archive}
+	err = reader.open(sheet)
+	if err != nil {
+		archive.Close()
+		return nil, err
+	}
+	return reader, nil
+}
+
+func (r *XLSXReader) open(sheet string) error {
+	var workbook xlsxWorkbook
+	err := r.unmarshal("xl/workbook.xml", &workbook)
+	if err != nil {
+		return err
+	}
+
+	var rels xlsxRelationships
+	err = r.unmarshal("xl/_rels/workbook.xml.rels", &rels)
+	if err != nil {
+		return err
+	}
+
+	rid := ""
+	name := strings.TrimSpace(sheet)
+	position, numberErr := strconv.Atoi(name)
+	for i, s := range workbook.Sheets {
+		if (name == "" && i == 0) || name == s.Name || (numberErr == nil && position == i+1) {
+			rid = s.RID
+			break
+		}
+	}
+	if rid == "" {
+		return fmt.Errorf("sheet tidak ditemukan: %s", sheet)
+	}
+	r.date1904 = workbook.Properties.Date1904
+
+	target, sharedStrings, styles := "", "xl/sharedStrings.xml", "xl/styles.xml"
+	for _, rel := range rels.Relationships {
+		if rel.ID == rid {
+			target = partName(rel.Target)
+		}
+		if strings.HasSuffix(rel.Type, "/sharedStrings") {
+			sharedStrings = partName(rel.Target)
+		}
+		if strings.HasSuffix(rel.Type, "/styles") {
+			styles = partName(rel.Target)
+		}
+	}
+
+	r.shared, err = r.readSharedStrings(sharedStrings)
+	if err != nil {
+		return err
+	}
+
+	r.dateStyles, err = r.readDateStyles(styles)
+	if err != nil {
+		return err
+	}
+
+	file := r.file(target)
+	if file == nil {
+		return fmt.Errorf("sheet tidak ditemukan: %s", sheet)
+	}
+
+	r.sheet, err = file.Open()
+	if err != nil {
+		return err
+	}
+	r.decoder = xml.NewDecoder(r.sheet)
+	return nil
+}
+
+// partName resolves a target of the workbook relationships to the name of the part in the archive
+func partName(target string) string {
+	if strings.HasPrefix(target, "/") {
+		return strings.TrimPrefix(target, "/")
+	}
+	return path.Join("xl", target)
+}
+
+// file looks the part up ignoring case, some writers do not keep the case of the relationship target
+func (r *XLSXReader) file(name string) *zip.File {
+	for _, file := range r.archive.File {
+		if strings.EqualFold(file.Name, name) {
+			return file
+		}
+	}
+	return nil
+}
+
+func (r *XLSXReader) unmarshal(name string, v interface{}) error {
+	file := r.file(name)
+	if file == nil {
+		return fmt.Errorf("file xlsx tidak valid: %s tidak ditemukan", name)
+	}
+
+	content, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	return xml.NewDecoder(content).Decode(v)
+}
+
+func (r *XLSXReader) readSharedStrings(name string) ([]string, error) {
+	file := r.file(name)
+	if file == nil {
+		return nil, nil
+	}
+
+	content, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	var shared []string
+	decoder := xml.NewDecoder(content)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return shared, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local == "si" {
+			text, err := readText(decoder, "si")
+			if err != nil {
+				return nil, err
+			}
+			shared = append(shared, text)
+		}
+	}
+}
+
+// readDateStyles tells for every cell style whether its number format shows a date, a workbook without styles
+// has no dates
+func (r *XLSXReader) readDateStyles(name string) ([]bool, error) {
+	if r.file(name) == nil {
+		return nil, nil
+	}
+
+	var styles xlsxStyles
+	err := r.unmarshal(name, &styles)
+	if err != nil {
+		return nil, err
+	}
+
+	codes := make(map[int]string)
+	for _, numFmt := range styles.NumFmts {
+		codes[numFmt.ID] = numFmt.Code
+	}
+
+	dateStyles := make([]bool, len(styles.CellXfs))
+	for i, xf := range styles.CellXfs {
+		if code, ok := codes[xf.NumFmtID]; ok {
+			dateStyles[i] = isDateFormat(code)
+		} else {
+			dateStyles[i] = isDateFormatID(xf.NumFmtID)
+		}
+	}
+	return dateStyles, nil
+}
+
+// isDateFormatID tells whether a built-in number format shows a date or a time
+func isDateFormatID(id int) bool {
+	return (id >= 14 && id <= 22) || (id >= 45 && id <= 47)
+}
+
+// isDateFormat tells whether a custom number format shows a date or a time, the quoted texts, escaped characters
+// and bracketed colors or currencies of the format are not part of the pattern
+func isDateFormat(code string) bool {
+	quoted, bracketed, escaped := false, false, false
+	for _, c := range strings.ToLower(code) {
+		switch {
+		case escaped:
+			escaped = false
+		case quoted:
+			quoted = c != '"'
+		case bracketed:
+			bracketed = c != ']'
+		case c == '\\':
+			escaped = true
+		case c == '"':
+			quoted = true
+		case c == '[':
+			bracketed = true
+		case c == ';':
+			// only the format of positive numbers is looked at
+			return false
+		case strings.ContainsRune("ymdhs", c):
+			return true
+		}
+	}
+	return false
+}
+
+// readText joins every text of the element up to its end, phonetic hints are skipped
+func readText(decoder *xml.Decoder, end string) (string, error) {
+	var text strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "rPh" {
+				err = decoder.Skip()
+			} else if t.Name.Local == "t" {
+				var value string
+				err = decoder.DecodeElement(&value, &t)
+				text.WriteString(value)
+			}
+			if err != nil {
+				return "", err
+			}
+		case xml.EndElement:
+			if t.Name.Local == end {
+				return text.String(), nil
+			}
+		}
+	}
+}
+
+// Next returns the cells of the next row that has any cell, io.EOF after the last row
+func (r *XLSXReader) Next() ([]string, error) {
+	for {
+		token, err := r.decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "row" {
+				r.line++
+				if number, err := strconv.Atoi(attr(t, "r")); err == nil {
+					r.line = number
+				}
+				return r.readRow()
+			}
+		case xml.EndElement:
+			if t.Name.Local == "sheetData" {
+				return nil, io.EOF
+			}
+		}
+	}
+}
+
+// Line is the row number of the row last returned by Next
+func (r *XLSXReader) Line() int {
+	return r.line
+}
+
+func (r *XLSXReader) readRow() ([]string, error) {
+	var cells []string
+	for {
+		token, err := r.decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local != "c" {
+				continue
+			}
+
+			column := len(cells)
+			if ref := attr(t, "r"); ref != "" {
+				column = columnIndex(ref)
+			}
+
+			value, err := r.readCell(t)
+			if err != nil {
+				return nil, err
+			}
+
+			for len(cells) <= column {
+				cells = append(cells, "")
+			}
+			cells[column] = value
+		case xml.EndElement:
+			if t.Name.Local == "row" {
+				return cells, nil
+			}
+		}
+	}
+}
+
+func (r *XLSXReader) readCell(start xml.StartElement) (string, error) {
+	cellType := attr(start, "t")
+	value := ""
+	for {
+		token, err := r.decoder.Token()
+		if err != nil {
+			return "", err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "v":
+				err = r.decoder.DecodeElement(&value, &t)
+			case "is":
+				value, err = readText(r.decoder, "is")
+			default:
+				err = r.decoder.Skip()
+			}
+			if err != nil {
+				return "", err
+			}
+		case xml.EndElement:
+			if t.Name.Local != "c" {
+				continue
+			}
+
+			switch cellType {
+			case "s":
+				index, err := strconv.Atoi(value)
+				if err != nil || index < 0 || index >= len(r.shared) {
+					return "", fmt.Errorf("shared string tidak valid: %s", value)
+				}
+				return r.shared[index], nil
+			case "b":
+				if value == "1" {
+					return "TRUE", nil
+				}
+				return "FALSE", nil
+			case "", "n":
+				return r.formatNumber(value, attr(start, "s")), nil
+			}
+			return value, nil
+		}
+	}
+}
+
+// formatNumber returns a number cell the way it is shown: a date when its style is a date format, otherwise
+// the number without the exponent some writers put on large numbers such as a NIK
+func (r *XLSXReader) formatNumber(value, style string) string {
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return value
+	}
+
+	if index, err := strconv.Atoi(style); err == nil && index >= 0 && index < len(r.dateStyles) && r.dateStyles[index] {
+		date := excelDate(number, r.date1904)
+		if date.Hour() == 0 && date.Minute() == 0 && date.Second() == 0 {
+			return date.Format(xlsxDateLayout)
+		}
+		return date.Format(xlsxDateTimeLayout)
+	}
+
+	if strings.ContainsAny(value, "eE") {
+		return strconv.FormatFloat(number, 'f', -1, 64)
+	}
+	return value
+}
+
+// excelDate converts the serial number of a date cell, Excel counts 29 February 1900 that did not exist
+// so serials from 1 March 1900 start a day earlier
+func excelDate(serial float64, date1904 bool) time.Time {
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	if date1904 {
+		epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+	} else if serial < 61 {
+		epoch = time.Date(1899, 12, 31, 0, 0, 0, 0, time.UTC)
+	}
+
+	days := int(serial)
+	seconds := int((serial-float64(days))*86400 + 0.5)
+	return epoch.AddDate(0, 0, days).Add(time.Duration(seconds) * time.Second)
+}
+
+func (r *XLSXReader) Close() error {
+	if r.sheet != nil {
+		r.sheet.Close()
+	}
+	return r.archive.Close()
+}
+
+func attr(start xml.StartElement, name string) string {
+	for _, a := range start.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// columnIndex converts the letters of a cell reference such as AB12 to a zero based column
+func columnIndex(ref string) int {
+	column := 0
+	for _, c := range strings.ToUpper(ref) {
+		if c < 'A' || c > 'Z' {
+			break
+		}
+		column = column*26 + int(c-'A'+1)
+	}
+	return column - 1
+}
+
+// columnName converts a zero based column to its letters
+func columnName(column int) string {
+	name := ""
+	for column >= 0 {
+		name = string(rune('A'+column%26)) + name
+		column = column/26 - 1
+	}
+	return name
+}
+
+// XLSXWriter writes a single sheet workbook row by row without keeping the rows in memory
+type XLSXWriter struct {
+	file    *os.File
+	archive *zip.Writer
+	sheet   io.Writer
+	line    int
+}
+
+func CreateXLSX(filename, sheet string) (*XLSXWriter, error) {
+	file, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	writer := &XLSXWriter{file: file, archive: zip.NewWriter(file)}
+	err = writer.writeParts(sheet)
+	if err != nil {
+		writer.archive.Close()
+		file.Close()
+		return nil, err
+	}
+	return writer, nil
+}
+
+func (w *XLSXWriter) writeParts(sheet string) error {
+	var name strings.Builder
+	_ = xml.EscapeText(&name, []byte(sheet))
+
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+			`</Types>`},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="` + xlsxRelationshipNS + `">` +
+			`<sheets><sheet name="` + name.String() + `" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+			`</Relationships>`},
+	}
+
+	for _, part := range parts {
+		writer, err := w.archive.Create(part.name)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(writer, part.content)
+		if err != nil {
+			return err
+		}
+	}
+
+	var err error
+	w.sheet, err = w.archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w.sheet, xml.Header+`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	return err
+}
+
+// WriteRow appends a row of text cells
+func (w *XLSXWriter) WriteRow(cells []string) error {
+	w.line++
+
+	var row strings.Builder
+	row.WriteString(`<row r="` + strconv.Itoa(w.line) + `">`)
+	for i, cell := range cells {
+		row.WriteString(`<c r="` + columnName(i) + strconv.Itoa(w.line) + `" t="inlineStr"><is><t xml:space="preserve">`)
+		_ = xml.EscapeText(&row, []byte(cell))
+		row.WriteString(`</t></is></c>`)
+	}
+	row.WriteString(`</row>`)
+
+	_, err := io.WriteString(w.sheet, row.String())
+	return err
+}
+
+func (w *XLSXWriter) Close() error {
+	_, err := io.WriteString(w.sheet, `</sheetData></worksheet>`)
+	if err == nil {
+		err = w.archive.Close()
+	}
+	if closeErr := w.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,263 @@
+/*
+ * Created on 21/10/26 11.05
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package helper
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testSheetNS = `xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"`
+
+// writeTestXLSX writes a workbook with the sheet data and the optional shared strings and styles parts
+func writeTestXLSX(t *testing.T, workbookPr, sheetData, sharedStrings, styles string) string {
+	t.Helper()
+
+	rels := `<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="/xl/worksheets/sheet2.xml"/>`
+	parts := map[string]string{
+		"xl/workbook.xml": xml.Header + `<workbook ` + testSheetNS + ` xmlns:r="` + xlsxRelationshipNS + `">` + workbookPr +
+			`<sheets><sheet name="Data" sheetId="1" r:id="rId1"/><sheet name="Kosong" sheetId="2" r:id="rId2"/></sheets></workbook>`,
+		"xl/worksheets/sheet1.xml": xml.Header + `<worksheet ` + testSheetNS + `><sheetData>` + sheetData + `</sheetData></worksheet>`,
+		"xl/worksheets/sheet2.xml": xml.Header + `<worksheet ` + testSheetNS + `><sheetData/></worksheet>`,
+	}
+	if sharedStrings != "" {
+		parts["xl/sharedStrings.xml"] = xml.Header + `<sst ` + testSheetNS + `>` + sharedStrings + `</sst>`
+	}
+	if styles != "" {
+		rels += `<Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`
+		parts["xl/styles.xml"] = xml.Header + `<styleSheet ` + testSheetNS + `>` + styles + `</styleSheet>`
+	}
+	parts["xl/_rels/workbook.xml.rels"] = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` + rels + `</Relationships>`
+
+	filename := filepath.Join(t.TempDir(), "test.xlsx")
+	file, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	archive := zip.NewWriter(file)
+	for name, content := range parts {
+		writer, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = io.WriteString(writer, content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+type testRow struct {
+	line  int
+	cells []string
+}
+
+func readTestXLSX(t *testing.T, filename, sheet string) []testRow {
+	t.Helper()
+
+	reader, err := OpenXLSX(filename, sheet)
+	if err != nil {
+		t.Fatalf("OpenXLSX() error = %v", err)
+	}
+	defer reader.Close()
+
+	var rows []testRow
+	for {
+		cells, err := reader.Next()
+		if err == io.EOF {
+			return rows
+		}
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		rows = append(rows, testRow{line: reader.Line(), cells: cells})
+	}
+}
+
+func TestXLSXReader(t *testing.T) {
+	const styles = `<numFmts count="2"><numFmt numFmtId="164" formatCode="dd/mm/yyyy"/><numFmt numFmtId="165" formatCode="&quot;Rp&quot;#,##0"/></numFmts>` +
+		`<cellXfs count="5"><xf numFmtId="0"/><xf numFmtId="14"/><xf numFmtId="164"/><xf numFmtId="165"/><xf numFmtId="22"/></cellXfs>`
+
+	tests := []struct {
+		name          string
+		workbookPr    string
+		sheetData     string
+		sharedStrings string
+		styles        string
+		want          []testRow
+	}{
+		{
+			name:          "shared strings",
+			sheetData:     `<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="s"><v>2</v></c></row>`,
+			sharedStrings: `<si><t>Nama</t></si><si><r><t>Jenis </t></r><r><t>Kelamin</t></r></si><si><t>Budi</t><rPh><t>ぶでぃ</t></rPh></si>`,
+			want:          []testRow{{1, []string{"Nama", "Jenis Kelamin", "Budi"}}},
+		},
+		{
+			name:      "inline strings",
+			sheetData: `<row r="1"><c r="A1" t="inlineStr"><is><t xml:space="preserve"> Siti </t></is></c><c r="B1" t="str"><f>A1</f><v>Siti</v></c></row>`,
+			want:      []testRow{{1, []string{" Siti ", "Siti"}}},
+		},
+		{
+			name: "sparse references",
+			sheetData: `<row r="2"><c r="B2" t="inlineStr"><is><t>b</t></is></c><c r="D2" t="inlineStr"><is><t>d</t></is></c></row>` +
+				`<row r="5"><c r="AA5"><v>1</v></c></row><row><c><v>2</v></c><c><v>3</v></c></row>`,
+			want: []testRow{
+				{2, []string{"", "b", "", "d"}},
+				{5, append(make([]string, 26), "1")},
+				{6, []string{"2", "3"}},
+			},
+		},
+		{
+			name:      "numeric nik",
+			sheetData: `<row r="1"><c r="A1"><v>3201014508900001</v></c><c r="B1" t="n"><v>3.201014508900001E+15</v></c><c r="C1"><v>0.5</v></c></row>`,
+			want:      []testRow{{1, []string{"3201014508900001", "3201014508900001", "0.5"}}},
+		},
+		{
+			name: "dates",
+			sheetData: `<row r="1"><c r="A1" s="1"><v>45000</v></c><c r="B1" s="2"><v>33090</v></c><c r="C1" s="3"><v>45000</v></c>` +
+				`<c r="D1" s="4"><v>45000.5</v></c><c r="E1" s="1"><v>1</v></c><c r="F1" s="1"><v>61</v></c><c r="G1"><v>45000</v></c></row>`,
+			styles: styles,
+			want:   []testRow{{1, []string{"2023-03-15", "1990-08-05", "45000", "2023-03-15 12:00:00", "1900-01-01", "1900-03-01", "45000"}}},
+		},
+		{
+			name:       "1904 dates",
+			workbookPr: `<workbookPr date1904="1"/>`,
+			sheetData:  `<row r="1"><c r="A1" s="1"><v>0</v></c></row>`,
+			styles:     styles,
+			want:       []testRow{{1, []string{"1904-01-01"}}},
+		},
+		{
+			name:      "booleans and errors",
+			sheetData: `<row r="1"><c r="A1" t="b"><v>1</v></c><c r="B1" t="b"><v>0</v></c><c r="C1" t="e"><v>#N/A</v></c></row>`,
+			want:      []testRow{{1, []string{"TRUE", "FALSE", "#N/A"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := writeTestXLSX(t, tt.workbookPr, tt.sheetData, tt.sharedStrings, tt.styles)
+			if got := readTestXLSX(t, filename, ""); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rows = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenXLSXSheet(t *testing.T) {
+	filename := writeTestXLSX(t, "", `<row r="1"><c r="A1"><v>1</v></c></row>`, "", "")
+
+	tests := []struct {
+		sheet   string
+		rows    int
+		wantErr bool
+	}{
+		{"", 1, false},
+		{"Data", 1, false},
+		{"1", 1, false},
+		{"Kosong", 0, false},
+		{"2", 0, false},
+		{"Lainnya", 0, true},
+		{"3", 0, true},
+	}
+
+	for _, tt := range tests {
+		reader, err := OpenXLSX(filename, tt.sheet)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("OpenXLSX(%q) error = %v, wantErr %v", tt.sheet, err, tt.wantErr)
+		}
+		if err != nil {
+			continue
+		}
+		reader.Close()
+
+		if rows := readTestXLSX(t, filename, tt.sheet); len(rows) != tt.rows {
+			t.Errorf("OpenXLSX(%q) rows = %d, want %d", tt.sheet, len(rows), tt.rows)
+		}
+	}
+}
+
+func TestXLSXWriter(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "hasil.xlsx")
+	writer, err := CreateXLSX(filename, "Hasil & Catatan")
+	if err != nil {
+		t.Fatalf("CreateXLSX() error = %v", err)
+	}
+
+	want := [][]string{{"Nama", "NIK", "Catatan"}, {"Budi <Santoso>", "3201014508900001", ""}}
+	for _, row := range want {
+		if err = writer.WriteRow(row); err != nil {
+			t.Fatalf("WriteRow() error = %v", err)
+		}
+	}
+	if err = writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	rows := readTestXLSX(t, filename, "Hasil & Catatan")
+	if len(rows) != len(want) {
+		t.Fatalf("rows = %v, want %v", rows, want)
+	}
+	for i, row := range rows {
+		if row.line != i+1 || !reflect.DeepEqual(row.cells, want[i]) {
+			t.Errorf("row %d = %v, want %v", i+1, row, want[i])
+		}
+	}
+}
+
+func TestColumnIndex(t *testing.T) {
+	tests := []struct {
+		ref    string
+		column int
+	}{
+		{"A1", 0}, {"Z9", 25}, {"AA10", 26}, {"az3", 51}, {"XFD1048576", 16383},
+	}
+
+	for _, tt := range tests {
+		if got := columnIndex(tt.ref); got != tt.column {
+			t.Errorf("columnIndex(%q) = %d, want %d", tt.ref, got, tt.column)
+		}
+		if got := columnName(tt.column); got != strings.ToUpper(tt.ref[:len(got)]) {
+			t.Errorf("columnName(%d) = %q", tt.column, got)
+		}
+	}
+}
+
+func TestIsDateFormat(t *testing.T) {
+	tests := []struct {
+		code string
+		want bool
+	}{
+		{"dd/mm/yyyy", true},
+		{"[$-421]dd mmmm yyyy", true},
+		{"h:mm AM/PM", true},
+		{"General", false},
+		{"0.00", false},
+		{"@", false},
+		{`"Rp"#,##0`, false},
+		{`#,##0 \d`, false},
+		{"[Red]0.00", false},
+		{"0;[Red]-0;\"day\"", false},
+	}
+
+	for _, tt := range tests {
+		if got := isDateFormat(tt.code); got != tt.want {
+			t.Errorf("isDateFormat(%q) = %v, want %v", tt.code, got, tt.want)
+		}
+	}
+}
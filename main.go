@@ -11,13 +11,14 @@ import (
 	participantHandler "bumn-sembako-be/handler/participant"
 	regionHandler "bumn-sembako-be/handler/region"
 	userHandler "bumn-sembako-be/handler/user"
+	"bumn-sembako-be/helper"
+	"bumn-sembako-be/middleware"
 	participantService "bumn-sembako-be/service/participant"
 	regionService "bumn-sembako-be/service/region"
 	userService "bumn-sembako-be/service/user"
 	participantUsecase "bumn-sembako-be/usecase/participant"
 	regionUsecase "bumn-sembako-be/usecase/region"
 	userUsecase "bumn-sembako-be/usecase/user"
-	"fmt"
 	"net/http"
 	"time"
 
@@ -37,7 +38,7 @@ func main() {
 	viper.AutomaticEnv()
 	err := viper.ReadInConfig()
 	if err != nil {
-		fmt.Errorf("error: %v", err)
+		helper.CommonLogger().Error(err)
 		return
 	}
 
@@ -48,6 +49,12 @@ func main() {
 	dbPort := viper.Get("DB_PORT").(string)
 	dbName := viper.Get("DB_NAME").(string)
 
+	err = helper.LoadKeyRing()
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		return
+	}
+
 	db := config.DbConnect(dbUser, dbPass, dbHost, dbPort, dbName)
 
 	router.Use(cors.New(cors.Config{
@@ -79,41 +86,73 @@ func main() {
 	ph := participantHandler.NewHandler(pu)
 
 	router.StaticFS("/bumn-sembako/api/template", http.Dir("templates"))
-	v1.StaticFS("/image", http.Dir("uploads"))
 
-	v1.POST("login", uh.Login)
+	v1.POST("login", middleware.LoginRateLimit(), uh.Login)
+	v1.POST("refresh", uh.Refresh)
 	v1.POST("register", uh.Register)
 	v1.POST("register-yayasan", uh.RegisterYayasan)
-	v1.GET("dashboard", ph.ViewDashboard)
-	v1.GET("excel", ph.ExportExcel)
-	v1.POST("report/export", ph.ExportReport)
-	v1.POST("report/excel/export", ph.ExportExcelData)
-	v1.POST("report/csv/export", ph.ExportCSVData)
-	v1.POST("report/export-new", ph.ExportReportV2)
-	v1.GET("photo/:path", ph.ImageHandler)
-	v1.GET("photobase64/:path", ph.ImageBase64Handler)
+	v1.GET("user/organization", uh.ViewOrganizations)
+	v1.GET("user/organization/eo", uh.ViewEOOrganizations)
+	v1.GET("user/organization/yayasan", uh.ViewYayasanOrganizations)
+
+	auth := v1.Group("", middleware.Auth(uu))
+	auth.POST("logout", uh.Logout)
+	auth.POST("logout-all", uh.LogoutAll)
+	auth.PUT("me/password", uh.ChangePassword)
+	auth.GET("me/permissions", uh.ViewPermissions)
+
+	active := auth.Group("", middleware.PasswordChanged())
+	active.GET("dashboard", middleware.Permit(userUsecase.PERMISSION_PARTICIPANT_VIEW), ph.ViewDashboard)
+	active.GET("dashboard/age-group", middleware.Permit(userUsecase.PERMISSION_PARTICIPANT_VIEW), ph.ViewAgeGroups)
+	active.GET("excel", middleware.Permit(userUsecase.PERMISSION_REPORT_EXPORT), ph.ExportExcel)
+	active.GET("photo/:path", middleware.Permit(userUsecase.PERMISSION_PARTICIPANT_VIEW), ph.ImageHandler)
+	active.GET("photobase64/:path", middleware.Permit(userUsecase.PERMISSION_PARTICIPANT_VIEW), ph.ImageBase64Handler)
+	active.GET("image/:filename", ph.ViewUpload)
+
+	report := active.Group("/report", middleware.Permit(userUsecase.PERMISSION_REPORT_EXPORT))
+	{
+		report.POST("export", ph.ExportReport)
+		report.POST("excel/export", ph.ExportExcelData)
+		report.POST("csv/export", ph.ExportCSVData)
+		report.POST("export-new", ph.ExportReportV2)
+	}
 	//
-	user := v1.Group("/user")
+	user := active.Group("/user", middleware.Permit(userUsecase.PERMISSION_USER_MANAGE))
 	{
 		user.GET("", uh.ViewUsers)
 		user.POST("", uh.CreateUser)
 		user.PUT("/:id", uh.UpdateUser)
 		user.DELETE("/:id", uh.DeleteUser)
-		user.GET("organization", uh.ViewOrganizations)
-		user.GET("organization/eo", uh.ViewEOOrganizations)
-		user.GET("organization/yayasan", uh.ViewYayasanOrganizations)
+		user.PUT("/:id/unlock", uh.UnlockUser)
+		user.PUT("/:id/reset-password", uh.ResetPassword)
 	}
 
-	participant := v1.Group("/participant")
+	participant := active.Group("/participant")
 	{
-		participant.GET("", ph.ViewParticipants)
-		participant.GET("/:id", ph.ViewParticipant)
-		participant.PUT("/:id", ph.Update)
-		participant.PUT("/edit/:id", ph.Edit)
-		participant.POST("import", ph.BulkCreate)
-		participant.GET("import", ph.ViewLogs)
-		participant.PUT("/reset/:id", ph.Reset)
-		participant.DELETE("/:id", ph.Delete)
+		participant.GET("", middleware.Permit(userUsecase.PERMISSION_PARTICIPANT_VIEW), ph.ViewParticipants)
+		participant.GET("/:id", middleware.Permit(userUsecase.PERMISSION_PARTICIPANT_VIEW), ph.ViewParticipant)
+		participant.GET("/:id/history", middleware.Permit(userUsecase.PERMISSION_PARTICIPANT_VIEW), ph.ViewHistory)
+		participant.GET("/:id/status-events", middleware.Permit(userUsecase.PERMISSION_PARTICIPANT_VIEW), ph.ViewStatusEvents)
+		participant.PUT("/:id", middleware.Permit(userUsecase.PERMISSION_PARTICIPANT_UPDATE), ph.Update)
+		participant.PUT("/edit/:id", middleware.Permit(userUsecase.PERMISSION_PARTICIPANT_EDIT), ph.Edit)
+		participant.POST("import", middleware.Permit(userUsecase.PERMISSION_PARTICIPANT_IMPORT), ph.BulkCreate)
+		participant.GET("import", middleware.Permit(userUsecase.PERMISSION_PARTICIPANT_IMPORT), ph.ViewLogs)
+		participant.GET("import/:id", middleware.Permit(userUsecase.PERMISSION_PARTICIPANT_IMPORT), ph.ViewImport)
+		participant.GET("import/:id/participants", middleware.Permit(userUsecase.PERMISSION_PARTICIPANT_IMPORT), ph.ViewImportParticipants)
+		participant.GET("import/:id/errors", middleware.Permit(userUsecase.PERMISSION_PARTICIPANT_IMPORT), ph.ViewImportErrors)
+		participant.GET("import/:id/errors/summary", middleware.Permit(userUsecase.PERMISSION_PARTICIPANT_IMPORT), ph.ViewImportErrorSummary)
+		participant.POST("import/:id/rollback", middleware.Permit(userUsecase.PERMISSION_PARTICIPANT_IMPORT), ph.RollbackImport)
+		participant.GET("import-mapping", middleware.Permit(userUsecase.PERMISSION_PARTICIPANT_IMPORT), ph.ViewMappings)
+		participant.POST("import-mapping", middleware.Permit(userUsecase.PERMISSION_PARTICIPANT_IMPORT), ph.CreateMapping)
+		participant.PUT("import-mapping/:id", middleware.Permit(userUsecase.PERMISSION_PARTICIPANT_IMPORT), ph.UpdateMapping)
+		participant.DELETE("import-mapping/:id", middleware.Permit(userUsecase.PERMISSION_PARTICIPANT_IMPORT), ph.DeleteMapping)
+		participant.GET("validation-rule", middleware.Permit(userUsecase.PERMISSION_VALIDATION_MANAGE), ph.ViewValidationRules)
+		participant.GET("validation-rule/effective", middleware.Permit(userUsecase.PERMISSION_VALIDATION_MANAGE), ph.ViewEffectiveValidationRules)
+		participant.POST("validation-rule", middleware.Permit(userUsecase.PERMISSION_VALIDATION_MANAGE), ph.CreateValidationRule)
+		participant.PUT("validation-rule/:id", middleware.Permit(userUsecase.PERMISSION_VALIDATION_MANAGE), ph.UpdateValidationRule)
+		participant.DELETE("validation-rule/:id", middleware.Permit(userUsecase.PERMISSION_VALIDATION_MANAGE), ph.DeleteValidationRule)
+		participant.PUT("/reset/:id", middleware.Permit(userUsecase.PERMISSION_PARTICIPANT_RESET), ph.Reset)
+		participant.DELETE("/:id", middleware.Permit(userUsecase.PERMISSION_PARTICIPANT_DELETE), ph.Delete)
 	}
 
 	region := v1.Group("/region")
@@ -123,10 +162,11 @@ func main() {
 		region.GET("district", rh.ViewDistrictsByRegencyId)
 		region.GET("village", rh.ViewVillagesByDistrictId)
 	}
+	active.POST("region/refresh", middleware.Permit(userUsecase.PERMISSION_REGION_MANAGE), rh.RefreshCache)
 
 	err = router.Run(":" + port)
 	if err != nil {
-		fmt.Errorf("error: %v", err)
+		helper.CommonLogger().Error(err)
 	}
 
 }
@@ -0,0 +1,102 @@
+/*
+ * Created on 18/10/26 08.45
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package middleware
+
+import (
+	"bumn-sembako-be/helper"
+	"bumn-sembako-be/model"
+	"bumn-sembako-be/usecase/user"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+)
+
+const authUserKey = "auth_user"
+const authSessionKey = "auth_session"
+
+// Auth validates the bearer token and puts the authenticated user into the request context
+func Auth(usecase user.Usecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if !strings.HasPrefix(header, "Bearer ") {
+			helper.HandleError(c, http.StatusUnauthorized, "authorization token is required")
+			c.Abort()
+			return
+		}
+
+		claims, err := helper.ValidateToken(strings.TrimSpace(strings.TrimPrefix(header, "Bearer ")))
+		if err != nil {
+			var validationErr *jwt.ValidationError
+			if errors.As(err, &validationErr) && validationErr.Errors&jwt.ValidationErrorExpired != 0 {
+				helper.HandleError(c, http.StatusUnauthorized, "token is expired")
+				c.Abort()
+				return
+			}
+
+			helper.CommonLogger().Error(err)
+			helper.HandleError(c, http.StatusUnauthorized, "token is invalid")
+			c.Abort()
+			return
+		}
+
+		authUser, err := usecase.ReadById(claims.UserID)
+		if err != nil {
+			helper.CommonLogger().Error(err)
+			helper.HandleError(c, http.StatusUnauthorized, "user is not exists")
+			c.Abort()
+			return
+		}
+
+		err = usecase.ValidateSession(claims.SessionID, authUser.ID)
+		if err != nil {
+			helper.HandleError(c, http.StatusUnauthorized, err.Error())
+			c.Abort()
+			return
+		}
+
+		c.Set(authUserKey, authUser)
+		c.Set(authSessionKey, claims.SessionID)
+		c.Next()
+	}
+}
+
+// PasswordChanged blocks users flagged with must_change_password until they set their own password
+func PasswordChanged() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authUser := AuthUser(c)
+		if authUser != nil && authUser.MustChangePassword {
+			helper.HandleError(c, http.StatusForbidden, user.ErrPasswordChangeRequired.Error())
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// AuthUser returns the user set by Auth, nil when the route is not protected
+func AuthUser(c *gin.Context) *model.User {
+	value, exists := c.Get(authUserKey)
+	if !exists {
+		return nil
+	}
+
+	authUser, ok := value.(*model.User)
+	if !ok {
+		return nil
+	}
+
+	return authUser
+}
+
+// AuthSession returns the session id of the access token, 0 when the route is not protected
+func AuthSession(c *gin.Context) int {
+	return c.GetInt(authSessionKey)
+}
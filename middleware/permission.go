@@ -0,0 +1,35 @@
+/*
+ * Created on 18/10/26 09.34
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package middleware
+
+import (
+	"bumn-sembako-be/helper"
+	"bumn-sembako-be/usecase/user"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Permit only lets the request through when the authenticated user's role has the permission
+func Permit(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authUser := AuthUser(c)
+		if authUser == nil {
+			helper.HandleError(c, http.StatusUnauthorized, "authorization token is required")
+			c.Abort()
+			return
+		}
+
+		if !user.HasPermission(authUser.Role, permission) {
+			helper.HandleError(c, http.StatusForbidden, "you don't have permission to access this resource")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,100 @@
+/*
+ * Created on 18/10/26 11.10
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package middleware
+
+import (
+	"bumn-sembako-be/helper"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+const defaultLoginIPMaxFailures = 20
+const defaultLoginIPWindowMinutes = 15
+
+type failureLimiter struct {
+	mu       sync.Mutex
+	failures map[string][]time.Time
+	max      int
+	window   time.Duration
+}
+
+func (l *failureLimiter) recent(key string, now time.Time) []time.Time {
+	var kept []time.Time
+	for _, t := range l.failures[key] {
+		if now.Sub(t) < l.window {
+			kept = append(kept, t)
+		}
+	}
+
+	if len(kept) == 0 {
+		delete(l.failures, key)
+	} else {
+		l.failures[key] = kept
+	}
+	return kept
+}
+
+func (l *failureLimiter) blocked(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return len(l.recent(key, time.Now())) >= l.max
+}
+
+func (l *failureLimiter) record(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.failures[key] = append(l.recent(key, now), now)
+
+	// drop addresses that stopped trying so the map does not grow forever
+	if len(l.failures) > 10000 {
+		for k := range l.failures {
+			l.recent(k, now)
+		}
+	}
+}
+
+// LoginRateLimit blocks an IP address after too many failed logins within the window,
+// configured with LOGIN_IP_MAX_FAILURES and LOGIN_IP_WINDOW_MINUTES in .env
+func LoginRateLimit() gin.HandlerFunc {
+	limiter := &failureLimiter{
+		failures: make(map[string][]time.Time),
+		max:      defaultLoginIPMaxFailures,
+		window:   defaultLoginIPWindowMinutes * time.Minute,
+	}
+
+	if v := viper.GetInt("LOGIN_IP_MAX_FAILURES"); v > 0 {
+		limiter.max = v
+	}
+
+	if v := viper.GetInt("LOGIN_IP_WINDOW_MINUTES"); v > 0 {
+		limiter.window = time.Duration(v) * time.Minute
+	}
+
+	return func(c *gin.Context) {
+		ip := c.ClientIP()
+		if limiter.blocked(ip) {
+			helper.CommonLogger().WithFields(logrus.Fields{"ip": ip}).Warn("login blocked for ip after failed attempts")
+			helper.HandleError(c, http.StatusTooManyRequests, "terlalu banyak percobaan login gagal, silahkan coba beberapa saat lagi")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+
+		if c.Writer.Status() == http.StatusUnauthorized || c.Writer.Status() == http.StatusTooManyRequests {
+			limiter.record(ip)
+		}
+	}
+}
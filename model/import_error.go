@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+// the error codes of a row that could not be imported
+const IMPORT_ERROR_REQUIRED = "REQUIRED"
+const IMPORT_ERROR_INVALID_FORMAT = "INVALID_FORMAT"
+const IMPORT_ERROR_INVALID_LENGTH = "INVALID_LENGTH"
+const IMPORT_ERROR_NIK_REGISTERED = "NIK_REGISTERED"
+const IMPORT_ERROR_NIK_DUPLICATE = "NIK_DUPLICATE"
+const IMPORT_ERROR_NIK_BIRTH_DATE = "NIK_BIRTH_DATE"
+const IMPORT_ERROR_NIK_GENDER_MISMATCH = "NIK_GENDER_MISMATCH"
+const IMPORT_ERROR_NIK_REGION_NOT_FOUND = "NIK_REGION_NOT_FOUND"
+const IMPORT_ERROR_REGION_NOT_FOUND = "REGION_NOT_FOUND"
+const IMPORT_ERROR_REGION_MISMATCH = "REGION_MISMATCH"
+const IMPORT_ERROR_UNKNOWN_STATUS = "UNKNOWN_STATUS"
+const IMPORT_ERROR_PARTICIPANT_DONE = "PARTICIPANT_DONE"
+const IMPORT_ERROR_OUT_OF_SCOPE = "OUT_OF_SCOPE"
+const IMPORT_ERROR_LOOKUP_FAILED = "LOOKUP_FAILED"
+const IMPORT_ERROR_NOT_SAVED = "NOT_SAVED"
+const IMPORT_ERROR_SKIPPED = "SKIPPED"
+const IMPORT_ERROR_INTERNAL = "INTERNAL_ERROR"
+
+// ImportError is one validation failure of a row, Column is the import field of the failing cell
+// and is empty when the failure concerns the whole row
+type ImportError struct {
+	ID          int       `json:"id" gorm:"primary_key"`
+	ImportLogID int       `json:"import_log_id" gorm:"index"`
+	Row         int       `json:"row"`
+	Column      string    `json:"column" gorm:"type:varchar(50)"`
+	Code        string    `json:"code" gorm:"type:varchar(50);index"`
+	Message     string    `json:"message" gorm:"type:text"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type ImportErrorCount struct {
+	Code  string `json:"code"`
+	Total int64  `json:"total"`
+}
@@ -2,18 +2,63 @@ package model
 
 import "time"
 
+const IMPORT_PROCESSING = "PROCESSING"
+const IMPORT_SUCCESS_ALL = "Success All"
+const IMPORT_SUCCESS_WITH_ERROR = "Success With error"
+const IMPORT_ERROR_ALL = "Error All"
+const IMPORT_FAILED = "FAILED"
+const IMPORT_ROLLED_BACK = "ROLLED_BACK"
+
+// IMPORT_MODE_INSERT only adds new participants, IMPORT_MODE_UPSERT also updates the participants matched by NIK
+const IMPORT_MODE_INSERT = "insert"
+const IMPORT_MODE_UPSERT = "upsert"
+
+// the result of every row in the import result workbook
+const IMPORT_ROW_INSERTED = "INSERTED"
+const IMPORT_ROW_UPDATED = "UPDATED"
+const IMPORT_ROW_UNCHANGED = "UNCHANGED"
+const IMPORT_ROW_FAILED = "FAILED"
+
+// IMPORT_MODE_PARTIAL keeps the valid rows of a file, IMPORT_MODE_ALL_OR_NOTHING inserts nothing when a row fails
+const IMPORT_MODE_PARTIAL = "partial"
+const IMPORT_MODE_ALL_OR_NOTHING = "all_or_nothing"
+
+// ImportDryRun is the validation summary of an import file that was not imported
+type ImportDryRun struct {
+	FileName      string `json:"file_name"`
+	TotalRows     int    `json:"total_rows"`
+	ValidRows     int    `json:"valid_rows"`
+	InsertedRows  int    `json:"inserted_rows"`
+	UpdatedRows   int    `json:"updated_rows"`
+	UnchangedRows int    `json:"unchanged_rows"`
+	FailedRows    int    `json:"failed_rows"`
+	Path          string `json:"path"`
+
+	// ErrorCounts is the number of errors per error code
+	ErrorCounts map[string]int `json:"error_counts"`
+}
+
 type ImportLog struct {
-	ID          int        `json:"id" gorm:"primary_key"`
-	FileName    string     `json:"file_name" gorm:"type:varchar(255)"`
-	Status      string     `json:"status"`
-	TotalRows   int        `json:"total_rows"`
-	SuccessRows int        `json:"success_rows"`
-	FailedRows  int        `json:"failed_rows"`
-	Path        string     `json:"path" gorm:"type: text"`
-	Reference   string     `json:"reference" gorm:"type:varchar(255)"`
-	UploadedBy  string     `json:"uploaded_by" gorm:"type:varchar(255)"`
-	Type        string     `json:"type" gorm:"type:varchar(100)"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
-	DeletedAt   *time.Time `sql:"index" json:"deleted_at"`
+	ID              int        `json:"id" gorm:"primary_key"`
+	FileName        string     `json:"file_name" gorm:"type:varchar(255)"`
+	Status          string     `json:"status"`
+	TotalRows       int        `json:"total_rows"`
+	ProcessedRows   int        `json:"processed_rows"`
+	SuccessRows     int        `json:"success_rows"`
+	InsertedRows    int        `json:"inserted_rows"`
+	UpdatedRows     int        `json:"updated_rows"`
+	UnchangedRows   int        `json:"unchanged_rows"`
+	FailedRows      int        `json:"failed_rows"`
+	Path            string     `json:"path" gorm:"type: text"`
+	Reference       string     `json:"reference" gorm:"type:varchar(255);index"`
+	UploadedBy      string     `json:"uploaded_by" gorm:"type:varchar(255)"`
+	Type            string     `json:"type" gorm:"type:varchar(100)"`
+	TransactionMode string     `json:"transaction_mode" gorm:"type:varchar(50)"`
+	Mode            string     `json:"mode" gorm:"type:varchar(50)"`
+	Fields          string     `json:"fields" gorm:"type:text"`
+	OverwriteDone   bool       `json:"overwrite_done" gorm:"default:false"`
+	Error           string     `json:"error" gorm:"type:text"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	DeletedAt       *time.Time `sql:"index" json:"deleted_at"`
 }
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ImportMapping is a saved column layout of a partner organization, Columns maps an import field to the header
+// used by the organization's files
+type ImportMapping struct {
+	ID             int             `json:"id" gorm:"primary_key"`
+	OrganizationID int             `json:"organization_id" gorm:"index"`
+	Name           string          `json:"name" gorm:"type:varchar(100)"`
+	Sheet          string          `json:"sheet" gorm:"type:varchar(100)"`
+	Columns        json.RawMessage `json:"columns" gorm:"type:json"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+	DeletedAt      *time.Time      `sql:"index" json:"deleted_at"`
+}
@@ -0,0 +1,16 @@
+/*
+ * Created on 21/10/26 16.55
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package model
+
+import "time"
+
+// Migration marks a one time data migration as done so it is not run again on the next start
+type Migration struct {
+	ID        int       `json:"id" gorm:"primary_key"`
+	Name      string    `json:"name" gorm:"type:varchar(100);uniqueIndex"`
+	CreatedAt time.Time `json:"created_at"`
+}
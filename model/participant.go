@@ -41,13 +41,41 @@ type Participant struct {
 	IsRepresented      bool           `json:"is_represented" gorm:"default:false"`
 	HasPrinted         bool           `json:"has_represented" gorm:"default:false"`
 	UpdatedBy          string         `json:"updated_by" gorm:"type:varchar(100)"`
-	Reference          string         `json:"reference" gorm:"type: text"`
+	Reference          string         `json:"reference" gorm:"type:varchar(255);index"`
 	Type               string         `json:"type" gorm:"type:varchar(100);index"`
+	BirthDate          *time.Time     `json:"birth_date" gorm:"type:date;index"`
+	NIKFlags           string         `json:"nik_flags" gorm:"type:varchar(100)"`
 	CreatedAt          time.Time      `json:"created_at" gorm:"index"`
 	UpdatedAt          time.Time      `json:"updated_at" gorm:"index"`
 	DeletedAt          gorm.DeletedAt `sql:"index" json:"deleted_at" gorm:"index"`
 }
 
+// the flags of a participant whose NIK was issued outside the region of the KTP address, separated by commas
+const NIK_FLAG_PROVINSI_MISMATCH = "PROVINSI_MISMATCH"
+const NIK_FLAG_KOTA_MISMATCH = "KOTA_MISMATCH"
+
+// AgeGroup bounds the age in years at both ends, a Max of 0 leaves the group open
+type AgeGroup struct {
+	Label string
+	Min   int
+	Max   int
+}
+
+const AGE_GROUP_UNKNOWN = "TIDAK DIKETAHUI"
+
+var AgeGroups = []AgeGroup{
+	{Label: "0-17", Min: 0, Max: 17},
+	{Label: "18-30", Min: 18, Max: 30},
+	{Label: "31-45", Min: 31, Max: 45},
+	{Label: "46-60", Min: 46, Max: 60},
+	{Label: "61+", Min: 61},
+}
+
+type AgeGroupCount struct {
+	AgeGroup string `json:"age_group"`
+	Total    int64  `json:"total"`
+}
+
 type TotalParticipantResponse struct {
 	TotalPenerima      int64 `json:"total_penerima"`
 	TotalSudahMenerima int64 `json:"total_sudah_menerima"`
@@ -75,10 +103,17 @@ type Report struct {
 	Phone    string
 	Image    string
 	Address  string
+	Handover *time.Time
 	ImageB64 template.URL
 	Total    int
 }
 
+// ParticipantUpdate is a partial update of one participant, Fields is keyed by column
+type ParticipantUpdate struct {
+	ID     int
+	Fields map[string]interface{}
+}
+
 type ReportPerFile struct {
 	Name string `json:"name"`
 	Path string `json:"path"`
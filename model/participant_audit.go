@@ -0,0 +1,36 @@
+/*
+ * Created on 18/10/26 16.12
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const AUDIT_UPDATE_STATUS = "UPDATE_STATUS"
+const AUDIT_EDIT = "EDIT"
+const AUDIT_RESET = "RESET"
+const AUDIT_DELETE = "DELETE"
+const AUDIT_REJECT = "REJECT"
+const AUDIT_CREATE_REPLACEMENT = "CREATE_REPLACEMENT"
+const AUDIT_IMPORT_UPDATE = "IMPORT_UPDATE"
+const AUDIT_IMPORT_ROLLBACK = "IMPORT_ROLLBACK"
+
+// ParticipantAudit is append-only, rows are never updated or deleted
+type ParticipantAudit struct {
+	ID            int             `json:"id" gorm:"primary_key"`
+	ParticipantID int             `json:"participant_id" gorm:"index"`
+	Action        string          `json:"action" gorm:"type:varchar(50);index"`
+	ActorID       int             `json:"actor_id" gorm:"index"`
+	Actor         string          `json:"actor" gorm:"type:varchar(100)"`
+	Before        json.RawMessage `json:"before" gorm:"type:json"`
+	After         json.RawMessage `json:"after" gorm:"type:json"`
+	Diff          json.RawMessage `json:"diff" gorm:"type:json"`
+	Reason        string          `json:"reason" gorm:"type:text"`
+	IP            string          `json:"ip" gorm:"type:varchar(100)"`
+	CreatedAt     time.Time       `json:"created_at" gorm:"index"`
+}
@@ -0,0 +1,58 @@
+/*
+ * Created on 18/10/26 05.09
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package model
+
+type ParticipantStatus string
+
+const STATUS_NOT_DONE ParticipantStatus = "NOT DONE"
+const STATUS_PARTIAL_DONE ParticipantStatus = "PARTIAL_DONE"
+const STATUS_DONE ParticipantStatus = "DONE"
+const STATUS_REJECTED ParticipantStatus = "REJECTED"
+
+// statusTransitions lists every status a participant may move to from a given status. Staying in the same status
+// is allowed so photos can be saved again, REJECTED is final because the rejected participant is removed and replaced
+var statusTransitions = map[ParticipantStatus][]ParticipantStatus{
+	STATUS_NOT_DONE:     {STATUS_NOT_DONE, STATUS_PARTIAL_DONE, STATUS_DONE, STATUS_REJECTED},
+	STATUS_PARTIAL_DONE: {STATUS_PARTIAL_DONE, STATUS_DONE, STATUS_REJECTED, STATUS_NOT_DONE},
+	STATUS_DONE:         {STATUS_DONE, STATUS_PARTIAL_DONE, STATUS_NOT_DONE},
+	STATUS_REJECTED:     {},
+}
+
+// statusOrder ranks the distribution progress, moving to a lower rank is a backward transition
+var statusOrder = map[ParticipantStatus]int{
+	STATUS_NOT_DONE:     0,
+	STATUS_PARTIAL_DONE: 1,
+	STATUS_DONE:         2,
+}
+
+func (s ParticipantStatus) IsValid() bool {
+	_, ok := statusTransitions[s]
+	return ok
+}
+
+func (s ParticipantStatus) CanTransitionTo(to ParticipantStatus) bool {
+	for _, next := range statusTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+func (s ParticipantStatus) IsBackward(to ParticipantStatus) bool {
+	from, ok := statusOrder[s]
+	if !ok {
+		return false
+	}
+
+	next, ok := statusOrder[to]
+	if !ok {
+		return false
+	}
+
+	return next < from
+}
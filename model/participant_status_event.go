@@ -0,0 +1,26 @@
+/*
+ * Created on 18/10/26 05.11
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package model
+
+import "time"
+
+// ParticipantStatusEvent is one step of the distribution timeline, the photos are kept per step
+// so evidence of an earlier step is not lost when the participant is updated again
+type ParticipantStatusEvent struct {
+	ID            int       `json:"id" gorm:"primary_key"`
+	ParticipantID int       `json:"participant_id" gorm:"index"`
+	FromStatus    string    `json:"from_status" gorm:"type:varchar(50)"`
+	ToStatus      string    `json:"to_status" gorm:"type:varchar(50);index"`
+	Image         string    `json:"image" gorm:"type: text"`
+	ImagePenerima string    `json:"image_penerima" gorm:"type: text"`
+	Latitude      *float64  `json:"latitude"`
+	Longitude     *float64  `json:"longitude"`
+	OfficerID     int       `json:"officer_id" gorm:"index"`
+	Officer       string    `json:"officer" gorm:"type:varchar(100)"`
+	Reason        string    `json:"reason" gorm:"type:text"`
+	CreatedAt     time.Time `json:"created_at" gorm:"index"`
+}
@@ -0,0 +1,16 @@
+/*
+ * Created on 18/10/26 05.05
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package model
+
+import "time"
+
+type PasswordHistory struct {
+	ID        int       `json:"id" gorm:"primary_key"`
+	UserID    int       `json:"user_id" gorm:"index"`
+	Password  string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
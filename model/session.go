@@ -0,0 +1,30 @@
+/*
+ * Created on 18/10/26 13.02
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package model
+
+import "time"
+
+type Session struct {
+	ID                       int        `json:"id" gorm:"primary_key"`
+	UserID                   int        `json:"user_id" gorm:"index"`
+	RefreshTokenHash         string     `json:"-" gorm:"type:varchar(64);uniqueIndex"`
+	PreviousRefreshTokenHash string     `json:"-" gorm:"type:varchar(64);index"`
+	UserAgent                string     `json:"user_agent" gorm:"type:varchar(255)"`
+	IP                       string     `json:"ip" gorm:"type:varchar(100)"`
+	ExpiresAt                time.Time  `json:"expires_at"`
+	LastUsedAt               *time.Time `json:"last_used_at"`
+	RevokedAt                *time.Time `json:"revoked_at" gorm:"index"`
+	CreatedAt                time.Time  `json:"created_at"`
+	UpdatedAt                time.Time  `json:"updated_at"`
+}
+
+type AuthToken struct {
+	Token            string    `json:"token"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
@@ -12,18 +12,21 @@ import (
 )
 
 type User struct {
-	gorm.Model     `json:"-"`
-	ID             int          `json:"id" gorm:"primary_key"`
-	Name           string       `json:"name"`
-	Username       string       `json:"username"`
-	Password       string       `json:"-"`
-	Role           string       `json:"role"`
-	Provinsi       string       `json:"provinsi"`
-	Kota           string       `json:"kota"`
-	OrganizationID uint         `json:"organization_id" gorm:"column:organization_id"`
-	Organization   Organization `json:"organization" gorm:"foreignKey:OrganizationID"`
-	RetryAttempts  int64        `json:"retry_attempts"`
-	CreatedAt      time.Time    `json:"created_at"`
-	UpdatedAt      time.Time    `json:"updated_at"`
-	DeletedAt      *time.Time   `sql:"index" json:"deleted_at"`
+	gorm.Model         `json:"-"`
+	ID                 int          `json:"id" gorm:"primary_key"`
+	Name               string       `json:"name"`
+	Username           string       `json:"username"`
+	Password           string       `json:"-"`
+	Role               string       `json:"role"`
+	Provinsi           string       `json:"provinsi"`
+	Kota               string       `json:"kota"`
+	OrganizationID     uint         `json:"organization_id" gorm:"column:organization_id"`
+	Organization       Organization `json:"organization" gorm:"foreignKey:OrganizationID"`
+	RetryAttempts      int64        `json:"retry_attempts"`
+	LockedUntil        *time.Time   `json:"locked_until"`
+	MustChangePassword bool         `json:"must_change_password" gorm:"default:false"`
+	PasswordChangedAt  *time.Time   `json:"password_changed_at"`
+	CreatedAt          time.Time    `json:"created_at"`
+	UpdatedAt          time.Time    `json:"updated_at"`
+	DeletedAt          *time.Time   `sql:"index" json:"deleted_at"`
 }
@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// ValidationRule overrides a participant validation rule of a program by name, an empty Type applies to every
+// program and a rule of the program wins over it. Fields is the comma separated list of checked fields
+type ValidationRule struct {
+	ID        int        `json:"id" gorm:"primary_key"`
+	Type      string     `json:"type" gorm:"type:varchar(100);index"`
+	Name      string     `json:"name" gorm:"type:varchar(100)"`
+	Kind      string     `json:"kind" gorm:"type:varchar(50)"`
+	Fields    string     `json:"fields" gorm:"type:varchar(500)"`
+	Min       int        `json:"min"`
+	Max       int        `json:"max"`
+	Chars     string     `json:"chars" gorm:"type:varchar(255)"`
+	Pattern   string     `json:"pattern" gorm:"type:varchar(255)"`
+	Message   string     `json:"message" gorm:"type:varchar(255)"`
+	Enabled   bool       `json:"enabled"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DeletedAt *time.Time `sql:"index" json:"deleted_at"`
+}
@@ -9,6 +9,7 @@ package request
 import (
 	"html/template"
 	"mime/multipart"
+	"time"
 )
 
 type ParticipantPaged struct {
@@ -34,6 +35,7 @@ type ParticipantFilter struct {
 }
 
 type ParticipantInput struct {
+	Row                int    `json:"row" form:"-"`
 	Name               string `json:"name" form:"name"`
 	NIK                string `json:"nik" form:"nik" `
 	Gender             string `json:"gender" form:"gender"`
@@ -57,6 +59,7 @@ type ParticipantInput struct {
 	Type               string `json:"type" form:"type"`
 	Status             string `json:"status" form:"status"`
 	Note               string `json:"note" form:"note"`
+	Result             string `json:"result" form:"-"`
 }
 
 type UpdateParticipant struct {
@@ -85,37 +88,42 @@ type UpdateParticipant struct {
 	ImagePenerima      string                `json:"image_penerima" form:"image_penerima"`
 	File               *multipart.FileHeader `json:"-" form:"file"`
 	FilePenerima       *multipart.FileHeader `json:"-" form:"file_penerima"`
-	UpdatedBy          string                `json:"updated_by" form:"updated_by"`
+	UpdatedBy          string                `json:"-" form:"-"`
 	Type               string                `json:"type" form:"type"`
+	Reason             string                `json:"reason" form:"reason"`
+	Latitude           *float64              `json:"latitude" form:"latitude"`
+	Longitude          *float64              `json:"longitude" form:"longitude"`
 }
 
 type ParticipantEditInput struct {
-	Name               string `json:"name" form:"name"`
-	NIK                string `json:"nik" form:"nik" `
-	Gender             string `json:"gender" form:"gender"`
-	Phone              string `json:"phone" form:"phone"`
-	Address            string `json:"address" form:"address"`
-	RT                 string `json:"rt" form:"rt"`
-	RW                 string `json:"rw" form:"rw"`
-	Provinsi           string `json:"provinsi" form:"provinsi"`
-	Kota               string `json:"kota" form:"kota"`
-	Kecamatan          string `json:"kecamatan" form:"kecamatan"`
-	Kelurahan          string `json:"kelurahan" form:"kelurahan"`
-	KodePOS            string `json:"kode_pos" form:"kode_pos"`
-	ResidenceAddress   string `json:"residence_address" form:"residence_address"`
-	ResidenceRT        string `json:"residence_rt" form:"residence_rt"`
-	ResidenceRW        string `json:"residence_rw" form:"residence_rw"`
-	ResidenceProvinsi  string `json:"residence_provinsi" form:"residence_provinsi"`
-	ResidenceKota      string `json:"residence_kota" form:"residence_kota"`
-	ResidenceKecamatan string `json:"residence_kecamatan" form:"residence_kecamatan"`
-	ResidenceKelurahan string `json:"residence_kelurahan" form:"residence_kelurahan"`
-	ResidenceKodePOS   string `json:"residence_kode_pos" form:"residence_kode_pos"`
-	Status             string `json:"status" form:"status"`
-	Image              string `json:"image" form:"image"`
-	ImagePenerima      string `json:"image_penerima" form:"image_penerima"`
-	UpdatedBy          string `json:"updated_by" form:"updated_by"`
-	HasPrinted         bool   `json:"has_printed"`
-	Type               string `json:"type" form:"type"`
+	Name               string     `json:"name" form:"name"`
+	NIK                string     `json:"nik" form:"nik" `
+	Gender             string     `json:"gender" form:"gender"`
+	Phone              string     `json:"phone" form:"phone"`
+	Address            string     `json:"address" form:"address"`
+	RT                 string     `json:"rt" form:"rt"`
+	RW                 string     `json:"rw" form:"rw"`
+	Provinsi           string     `json:"provinsi" form:"provinsi"`
+	Kota               string     `json:"kota" form:"kota"`
+	Kecamatan          string     `json:"kecamatan" form:"kecamatan"`
+	Kelurahan          string     `json:"kelurahan" form:"kelurahan"`
+	KodePOS            string     `json:"kode_pos" form:"kode_pos"`
+	ResidenceAddress   string     `json:"residence_address" form:"residence_address"`
+	ResidenceRT        string     `json:"residence_rt" form:"residence_rt"`
+	ResidenceRW        string     `json:"residence_rw" form:"residence_rw"`
+	ResidenceProvinsi  string     `json:"residence_provinsi" form:"residence_provinsi"`
+	ResidenceKota      string     `json:"residence_kota" form:"residence_kota"`
+	ResidenceKecamatan string     `json:"residence_kecamatan" form:"residence_kecamatan"`
+	ResidenceKelurahan string     `json:"residence_kelurahan" form:"residence_kelurahan"`
+	ResidenceKodePOS   string     `json:"residence_kode_pos" form:"residence_kode_pos"`
+	Status             string     `json:"status" form:"status"`
+	Image              string     `json:"image" form:"image"`
+	ImagePenerima      string     `json:"image_penerima" form:"image_penerima"`
+	UpdatedBy          string     `json:"updated_by" form:"updated_by"`
+	HasPrinted         bool       `json:"has_printed"`
+	Type               string     `json:"type" form:"type"`
+	BirthDate          *time.Time `json:"birth_date" form:"-"`
+	NIKFlags           *string    `json:"nik_flags" form:"-"`
 }
 
 type ParticipantDone struct {
@@ -147,10 +155,64 @@ type Report struct {
 }
 
 type ImportParticipant struct {
-	Name       string                `json:"name" form:"name"`
-	File       *multipart.FileHeader `json:"-" form:"file"`
-	Path       string                `json:"path" form:"path"`
-	TmpPath    string                `json:"tmp_path" form:"tmp_path"`
-	UploadedBy string                `json:"uploaded_by" form:"uploaded_by"`
-	Type       string                `json:"type" form:"type"`
+	Name            string                `json:"name" form:"name"`
+	File            *multipart.FileHeader `json:"-" form:"file"`
+	Path            string                `json:"path" form:"path"`
+	TmpPath         string                `json:"tmp_path" form:"tmp_path"`
+	UploadedBy      string                `json:"-" form:"-"`
+	Type            string                `json:"type" form:"type"`
+	TransactionMode string                `json:"transaction_mode" form:"transaction_mode"`
+	DryRun          bool                  `json:"dry_run" form:"dry_run"`
+	Mode            string                `json:"mode" form:"mode"`
+	Fields          string                `json:"fields" form:"fields"`
+	OverwriteDone   bool                  `json:"overwrite_done" form:"overwrite_done"`
+	UploadedByID    int                   `json:"-" form:"-"`
+	IP              string                `json:"-" form:"-"`
+	Sheet           string                `json:"sheet" form:"sheet"`
+	MappingID       int                   `json:"mapping_id" form:"mapping_id"`
+	OrganizationID  int                   `json:"-" form:"-"`
+}
+
+type ImportMappingInput struct {
+	OrganizationID int               `json:"organization_id"`
+	Name           string            `json:"name"`
+	Sheet          string            `json:"sheet"`
+	Columns        map[string]string `json:"columns"`
+}
+
+type ImportErrorPaged struct {
+	Page   int    `form:"page"`
+	Size   int    `form:"size"`
+	Code   string `form:"code"`
+	Column string `form:"column"`
+}
+
+type ValidationRuleInput struct {
+	Type    string   `json:"type"`
+	Name    string   `json:"name"`
+	Kind    string   `json:"kind"`
+	Fields  []string `json:"fields"`
+	Min     int      `json:"min"`
+	Max     int      `json:"max"`
+	Chars   string   `json:"chars"`
+	Pattern string   `json:"pattern"`
+	Message string   `json:"message"`
+	Enabled *bool    `json:"enabled"`
+}
+
+type ValidationRulePaged struct {
+	Type string `form:"type"`
+}
+
+type ImportMappingPaged struct {
+	OrganizationID int `form:"organization_id"`
+}
+
+type ResetParticipant struct {
+	Reason string `json:"reason" form:"reason"`
+}
+
+type HistoryPaged struct {
+	Page int `form:"page"`
+	Size int `form:"size"`
 }
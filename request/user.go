@@ -11,11 +11,15 @@ type Login struct {
 	Password string `json:"password"`
 }
 
+type RefreshToken struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
 type Register struct {
 	Name           string `json:"name"`
 	Provinsi       string `json:"provinsi"`
 	Kota           string `json:"kota"`
-	OrganizationID uint    `json:"organization_id"`
+	OrganizationID uint   `json:"organization_id"`
 	Username       string `json:"username"`
 	Password       string `json:"password"`
 }
@@ -33,7 +37,7 @@ type User struct {
 	Password       string `json:"password"`
 	Provinsi       string `json:"provinsi"`
 	Kota           string `json:"kota"`
-	OrganizationID uint    `json:"organization_id"`
+	OrganizationID uint   `json:"organization_id"`
 	RetryAttempts  int64  `json:"retry_attempts"`
 }
 
@@ -0,0 +1,116 @@
+/*
+ * Created on 18/10/26 16.20
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package participant
+
+import (
+	"bumn-sembako-be/helper"
+	"bumn-sembako-be/model"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+)
+
+type auditChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+func snapshot(participant *model.Participant) (json.RawMessage, map[string]interface{}, error) {
+	if participant == nil {
+		return nil, map[string]interface{}{}, nil
+	}
+
+	raw, err := json.Marshal(participant)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fields := make(map[string]interface{})
+	err = json.Unmarshal(raw, &fields)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return raw, fields, nil
+}
+
+// writeAudit stores the before/after state and the changed fields using the transaction of the change itself,
+// a nil audit means the caller does not want the change to be audited
+func writeAudit(tx *gorm.DB, audit *model.ParticipantAudit, before, after *model.Participant) error {
+	if audit == nil {
+		return nil
+	}
+
+	beforeRaw, beforeFields, err := snapshot(before)
+	if err != nil {
+		return err
+	}
+
+	afterRaw, afterFields, err := snapshot(after)
+	if err != nil {
+		return err
+	}
+
+	diff := make(map[string]auditChange)
+	for key := range beforeFields {
+		if _, ok := afterFields[key]; !ok {
+			afterFields[key] = nil
+		}
+	}
+
+	for key, value := range afterFields {
+		if key == "updated_at" {
+			continue
+		}
+		if !reflect.DeepEqual(beforeFields[key], value) {
+			diff[key] = auditChange{From: beforeFields[key], To: value}
+		}
+	}
+
+	diffRaw, err := json.Marshal(diff)
+	if err != nil {
+		return err
+	}
+
+	audit.Before = beforeRaw
+	audit.After = afterRaw
+	audit.Diff = diffRaw
+	if audit.ParticipantID == 0 {
+		if after != nil {
+			audit.ParticipantID = after.ID
+		} else if before != nil {
+			audit.ParticipantID = before.ID
+		}
+	}
+
+	return tx.Create(audit).Error
+}
+
+func (s *service) ReadAllAuditBy(criteria map[string]interface{}, page, size int) ([]*model.ParticipantAudit, error) {
+	var audits []*model.ParticipantAudit
+
+	limit, offset := helper.GetLimitOffset(page, size)
+	err := s.db.Where(criteria).Offset(offset).Limit(limit).Order("created_at DESC, id DESC").Find(&audits).Error
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[participant.service.ReadAllAuditBy] error execute query %v \n", err)
+		return nil, fmt.Errorf("failed view all data")
+	}
+	return audits, nil
+}
+
+func (s *service) CountAudits(criteria map[string]interface{}) int64 {
+	var result int64
+	err := s.db.Model(&model.ParticipantAudit{}).Where(criteria).Count(&result).Error
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		return 0
+	}
+	return result
+}
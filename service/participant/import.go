@@ -0,0 +1,116 @@
+/*
+ * Created on 18/10/26 22.05
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package participant
+
+import (
+	"bumn-sembako-be/helper"
+	"bumn-sembako-be/model"
+	"fmt"
+
+	"gorm.io/gorm/clause"
+)
+
+// RollbackImport soft-deletes every participant inserted by the import and marks the log ROLLED_BACK in one
+// transaction. Nothing is deleted when a participant of the import has already been processed, the returned
+// count is the number of those participants
+func (s *service) RollbackImport(importLogID int, reference string, audit *model.ParticipantAudit) (int64, error) {
+	tx := s.db.Begin()
+	defer tx.Rollback()
+
+	var participants []*model.Participant
+	err := tx.Table("participants").Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("reference = ?", reference).Where("deleted_at IS NULL").Find(&participants).Error
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[participant.service.RollbackImport] error execute query %v \n", err)
+		return 0, fmt.Errorf("failed view data")
+	}
+
+	var processed int64
+	for _, participant := range participants {
+		if model.ParticipantStatus(participant.Status) != model.STATUS_NOT_DONE {
+			processed++
+		}
+	}
+	if processed > 0 {
+		return processed, nil
+	}
+
+	for _, participant := range participants {
+		err = tx.Delete(participant).Error
+		if err == nil && audit != nil {
+			rowAudit := *audit
+			err = writeAudit(tx, &rowAudit, participant, nil)
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	if err == nil {
+		err = tx.Model(&model.ImportLog{}).Where("id = ?", importLogID).Update("status", model.IMPORT_ROLLED_BACK).Error
+	}
+
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[participant.service.RollbackImport] error execute query %v \n", err)
+		return 0, fmt.Errorf("failed delete data")
+	}
+
+	tx.Commit()
+
+	return 0, nil
+}
+
+func (s *service) CreateImportErrors(importErrors []*model.ImportError, batchSize int) error {
+	if len(importErrors) == 0 {
+		return nil
+	}
+
+	err := s.db.CreateInBatches(importErrors, batchSize).Error
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[participant.service.CreateImportErrors] error execute query %v \n", err)
+		return fmt.Errorf("failed insert data")
+	}
+	return nil
+}
+
+func (s *service) ReadAllImportErrorBy(criteria map[string]interface{}, page, size int) ([]*model.ImportError, error) {
+	var importErrors []*model.ImportError
+
+	limit, offset := helper.GetLimitOffset(page, size)
+	err := s.db.Where(criteria).Offset(offset).Limit(limit).Order("`row` ASC, id ASC").Find(&importErrors).Error
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[participant.service.ReadAllImportErrorBy] error execute query %v \n", err)
+		return nil, fmt.Errorf("failed view all data")
+	}
+	return importErrors, nil
+}
+
+func (s *service) CountImportErrors(criteria map[string]interface{}) int64 {
+	var result int64
+	err := s.db.Model(&model.ImportError{}).Where(criteria).Count(&result).Error
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[participant.service.CountImportErrors] error execute query %v \n", err)
+	}
+	return result
+}
+
+func (s *service) CountImportErrorsByCode(importLogID int) ([]*model.ImportErrorCount, error) {
+	var counts []*model.ImportErrorCount
+	err := s.db.Model(&model.ImportError{}).Select("code, COUNT(*) AS total").Where("import_log_id = ?", importLogID).
+		Group("code").Order("total DESC").Scan(&counts).Error
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[participant.service.CountImportErrorsByCode] error execute query %v \n", err)
+		return nil, fmt.Errorf("failed view all data")
+	}
+	return counts, nil
+}
@@ -0,0 +1,56 @@
+/*
+ * Created on 19/10/26 08.40
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package participant
+
+import (
+	"bumn-sembako-be/helper"
+	"bumn-sembako-be/model"
+	"fmt"
+	"time"
+)
+
+func (s *service) ReadAllMappingBy(criteria map[string]interface{}) ([]*model.ImportMapping, error) {
+	var mappings []*model.ImportMapping
+	err := s.db.Where(criteria).Where("deleted_at IS NULL").Order("name ASC").Find(&mappings).Error
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[participant.service.ReadAllMappingBy] error execute query %v \n", err)
+		return nil, fmt.Errorf("failed view all data")
+	}
+	return mappings, nil
+}
+
+func (s *service) ReadMappingById(id int) (*model.ImportMapping, error) {
+	var mapping = model.ImportMapping{}
+	err := s.db.Where("id = ?", id).Where("deleted_at IS NULL").First(&mapping).Error
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[participant.service.ReadMappingById] error execute query %v \n", err)
+		return nil, fmt.Errorf("id is not exists")
+	}
+	return &mapping, nil
+}
+
+func (s *service) SaveMapping(mapping *model.ImportMapping) (*model.ImportMapping, error) {
+	err := s.db.Save(mapping).Error
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[participant.service.SaveMapping] error execute query %v \n", err)
+		return nil, fmt.Errorf("failed insert data")
+	}
+	return mapping, nil
+}
+
+func (s *service) DeleteMapping(id int) error {
+	err := s.db.Model(&model.ImportMapping{}).Where("id = ?", id).Update("deleted_at", time.Now()).Error
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[participant.service.DeleteMapping] error execute query %v \n", err)
+		return fmt.Errorf("failed delete data")
+	}
+	return nil
+}
@@ -11,6 +11,7 @@ import (
 	"bumn-sembako-be/model"
 	"bumn-sembako-be/request"
 	"fmt"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -28,21 +29,44 @@ type Service interface {
 	CountLogs(criteria map[string]interface{}) int64
 	CountByDate(criteria map[string]interface{}, date time.Time) int64
 	CountByRangeDate(criteria map[string]interface{}, startDate, endDate time.Time) int64
-	Update(id int, participant *request.ParticipantEditInput) (*model.Participant, error)
-	UpdateStatus(id int, status *request.PartialDone) (*model.Participant, error)
-	Create(participant *model.Participant) (*model.Participant, error)
+	Update(id int, participant *request.ParticipantEditInput, audit *model.ParticipantAudit) (*model.Participant, error)
+	UpdateStatus(id int, status *request.PartialDone, audit *model.ParticipantAudit, event *model.ParticipantStatusEvent) (*model.Participant, error)
+	Create(participant *model.Participant, audit *model.ParticipantAudit, event *model.ParticipantStatusEvent) (*model.Participant, error)
+	RejectAndReplace(id int, status *request.PartialDone, event *model.ParticipantStatusEvent, replacement *model.Participant, replacementEvent *model.ParticipantStatusEvent, audit *model.ParticipantAudit) (*model.Participant, error)
+	ImportBatch(inserts []*model.Participant, updates []*model.ParticipantUpdate, batchSize int, audit *model.ParticipantAudit) error
+	ReadByNIK(nik string) (*model.Participant, error)
+	RollbackImport(importLogID int, reference string, audit *model.ParticipantAudit) (int64, error)
+	ReadAllMappingBy(criteria map[string]interface{}) ([]*model.ImportMapping, error)
+	ReadMappingById(id int) (*model.ImportMapping, error)
+	SaveMapping(mapping *model.ImportMapping) (*model.ImportMapping, error)
+	DeleteMapping(id int) error
+	CreateImportErrors(importErrors []*model.ImportError, batchSize int) error
+	ReadAllImportErrorBy(criteria map[string]interface{}, page, size int) ([]*model.ImportError, error)
+	CountImportErrors(criteria map[string]interface{}) int64
+	CountImportErrorsByCode(importLogID int) ([]*model.ImportErrorCount, error)
+	ReadAllValidationRuleBy(criteria map[string]interface{}) ([]*model.ValidationRule, error)
+	ReadValidationRuleById(id int) (*model.ValidationRule, error)
+	SaveValidationRule(rule *model.ValidationRule) (*model.ValidationRule, error)
+	DeleteValidationRule(id int) error
 	ReadAllReport(criteria map[string]interface{}, date time.Time) ([]*model.Report, error)
 	ReadAllReportByRangeDate(criteria map[string]interface{}, startDate, endDate time.Time) ([]*model.Report, error)
 	ReadAllReportByRangeDateV2(criteria map[string]interface{}, startDate, endDate time.Time, page, size int) ([]*model.Report, error)
 	ReadAllReportByRangeDateV3(criteria map[string]interface{}, startDate, endDate time.Time, page, size int) ([]*model.Report, error)
 	GetQuota(criteria map[string]interface{}) (*model.Quota, error)
 	CreateLog(m *model.ImportLog) (*model.ImportLog, error)
+	ReadLogById(id int) (*model.ImportLog, error)
+	UpdateLog(id int, fields map[string]interface{}) error
+	FailProcessingLogs(message string) (int64, error)
 	CountAllStatus(criteria map[string]interface{}) (*model.TotalParticipantResponse, error)
 	CountAllStatusGroup(criteria map[string]interface{}) ([]*model.TotalParticipantListResponse, error)
-	Reset(id int) (*model.Participant, error)
-	Delete(id int) error
+	CountAgeGroups(criteria map[string]interface{}) ([]*model.AgeGroupCount, error)
+	Reset(id int, audit *model.ParticipantAudit) (*model.Participant, error)
+	Delete(id int, audit *model.ParticipantAudit) error
 	DeleteBy(criteria map[string]interface{}) error
 	ReadAllDuplicates() ([]*model.Participant, error)
+	ReadAllAuditBy(criteria map[string]interface{}, page, size int) ([]*model.ParticipantAudit, error)
+	CountAudits(criteria map[string]interface{}) int64
+	ReadAllStatusEventBy(criteria map[string]interface{}) ([]*model.ParticipantStatusEvent, error)
 }
 
 type service struct {
@@ -142,6 +166,22 @@ func (s *service) ReadBy(criteria map[string]interface{}) ([]*model.Participant,
 	return participants, nil
 }
 
+// ReadByNIK returns the active participant with the NIK, or nil when there is none
+func (s *service) ReadByNIK(nik string) (*model.Participant, error) {
+	var participants []*model.Participant
+	err := s.db.Table("participants").Where("nik = ?", nik).Where("deleted_at IS NULL").Limit(1).Find(&participants).Error
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[participant.service.ReadByNIK] error execute query %v \n", err)
+		return nil, fmt.Errorf("failed view data")
+	}
+
+	if len(participants) == 0 {
+		return nil, nil
+	}
+	return participants[0], nil
+}
+
 func (s *service) Count(criteria map[string]interface{}, search string) int64 {
 	var result int64
 	query := s.db.Table("participants").Where(criteria).Where("deleted_at IS NULL")
@@ -234,36 +274,104 @@ func (s *service) CountByRangeDate(criteria map[string]interface{}, startDate, e
 	return result
 }
 
-func (e *service) Update(id int, participant *request.ParticipantEditInput) (*model.Participant, error) {
+func (e *service) Update(id int, participant *request.ParticipantEditInput, audit *model.ParticipantAudit) (*model.Participant, error) {
+	tx := e.db.Begin()
+	defer tx.Rollback()
+
+	var before = model.Participant{}
+	err := tx.Table("participants").Where("id = ?", id).First(&before).Error
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[participant.service.Update] error execute query %v \n", err)
+		return nil, fmt.Errorf("failed update data")
+	}
+
 	var upParticipant = model.Participant{}
-	err := e.db.Table("participants").Where("id = ?", id).First(&upParticipant).Updates(&participant).Error
+	err = tx.Table("participants").Where("id = ?", id).First(&upParticipant).Updates(&participant).Error
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[participant.service.Update] error execute query %v \n", err)
+		if helper.IsDuplicateKey(err) {
+			return nil, fmt.Errorf("NIK already exists")
+		}
+		return nil, fmt.Errorf("failed update data")
+	}
+
+	var after = model.Participant{}
+	err = tx.Table("participants").Where("id = ?", id).First(&after).Error
+	if err == nil {
+		err = writeAudit(tx, audit, &before, &after)
+	}
+	if err == nil {
+		err = writeStatusEvent(tx, nil, audit, &before, &after)
+	}
 	if err != nil {
 		helper.CommonLogger().Error(err)
 		fmt.Printf("[participant.service.Update] error execute query %v \n", err)
 		return nil, fmt.Errorf("failed update data")
 	}
+
+	tx.Commit()
+
 	return &upParticipant, nil
 }
 
-func (e *service) UpdateStatus(id int, status *request.PartialDone) (*model.Participant, error) {
+func (e *service) UpdateStatus(id int, status *request.PartialDone, audit *model.ParticipantAudit, event *model.ParticipantStatusEvent) (*model.Participant, error) {
+	tx := e.db.Begin()
+	defer tx.Rollback()
+
+	var before = model.Participant{}
+	err := tx.Table("participants").Where("id = ?", id).First(&before).Error
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[participant.service.UpdateStatus] error execute query %v \n", err)
+		return nil, fmt.Errorf("failed update data")
+	}
+
 	var upParticipant = model.Participant{}
-	err := e.db.Table("participants").Where("id = ?", id).First(&upParticipant).Updates(&status).Error
+	err = tx.Table("participants").Where("id = ?", id).First(&upParticipant).Updates(&status).Error
 	if err != nil {
 		helper.CommonLogger().Error(err)
 		fmt.Printf("[participant.service.UpdateStatus] error execute query %v \n", err)
 		return nil, fmt.Errorf("failed update data")
 	}
+
+	var after = model.Participant{}
+	err = tx.Table("participants").Where("id = ?", id).First(&after).Error
+	if err == nil {
+		err = writeAudit(tx, audit, &before, &after)
+	}
+	if err == nil {
+		err = writeStatusEvent(tx, event, audit, &before, &after)
+	}
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[participant.service.UpdateStatus] error execute query %v \n", err)
+		return nil, fmt.Errorf("failed update data")
+	}
+
+	tx.Commit()
+
 	return &upParticipant, nil
 }
 
-func (e *service) Create(participant *model.Participant) (*model.Participant, error) {
+func (e *service) Create(participant *model.Participant, audit *model.ParticipantAudit, event *model.ParticipantStatusEvent) (*model.Participant, error) {
 	tx := e.db.Begin()
 	defer tx.Rollback()
 
 	err := tx.Save(&participant).Error
+	if err == nil {
+		err = writeAudit(tx, audit, nil, participant)
+	}
+	if err == nil {
+		err = writeStatusEvent(tx, event, audit, nil, participant)
+	}
 	if err != nil {
 		helper.CommonLogger().Error(err)
 		fmt.Printf("[participant.service.Create] error execute query %v \n", err)
+		if helper.IsDuplicateKey(err) {
+			return nil, fmt.Errorf("NIK already exists")
+		}
 		return nil, fmt.Errorf("failed insert data")
 	}
 
@@ -272,10 +380,116 @@ func (e *service) Create(participant *model.Participant) (*model.Participant, er
 	return participant, nil
 }
 
+// RejectAndReplace rejects the participant, deletes it and stores its replacement in one transaction so a failed
+// replacement leaves the rejected participant as it was. The delete and the replacement are audited with copies
+// of the rejection audit
+func (e *service) RejectAndReplace(id int, status *request.PartialDone, event *model.ParticipantStatusEvent, replacement *model.Participant, replacementEvent *model.ParticipantStatusEvent, audit *model.ParticipantAudit) (*model.Participant, error) {
+	deleteAudit, createAudit := *audit, *audit
+	deleteAudit.Action, createAudit.Action = model.AUDIT_DELETE, model.AUDIT_CREATE_REPLACEMENT
+
+	tx := e.db.Begin()
+	defer tx.Rollback()
+
+	var before = model.Participant{}
+	err := tx.Table("participants").Where("id = ?", id).First(&before).Error
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[participant.service.RejectAndReplace] error execute query %v \n", err)
+		return nil, fmt.Errorf("id is not exists")
+	}
+
+	var rejected = model.Participant{}
+	err = tx.Table("participants").Where("id = ?", id).First(&rejected).Updates(&status).Error
+	if err == nil {
+		err = tx.Table("participants").Where("id = ?", id).First(&rejected).Error
+	}
+	if err == nil {
+		err = writeAudit(tx, audit, &before, &rejected)
+	}
+	if err == nil {
+		err = writeStatusEvent(tx, event, audit, &before, &rejected)
+	}
+	if err == nil {
+		err = tx.Delete(&rejected).Error
+	}
+	if err == nil {
+		err = writeAudit(tx, &deleteAudit, &rejected, nil)
+	}
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[participant.service.RejectAndReplace] error execute query %v \n", err)
+		return nil, fmt.Errorf("failed update data")
+	}
+
+	err = tx.Save(&replacement).Error
+	if err == nil {
+		err = writeAudit(tx, &createAudit, nil, replacement)
+	}
+	if err == nil {
+		err = writeStatusEvent(tx, replacementEvent, &createAudit, nil, replacement)
+	}
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[participant.service.RejectAndReplace] error execute query %v \n", err)
+		if helper.IsDuplicateKey(err) {
+			return nil, fmt.Errorf("NIK already exists")
+		}
+		return nil, fmt.Errorf("failed insert data")
+	}
+
+	tx.Commit()
+
+	return replacement, nil
+}
+
+// ImportBatch stores the inserts and updates of an import in one transaction, batchSize only limits the rows per
+// insert statement. Every update is audited with a copy of audit
+func (e *service) ImportBatch(inserts []*model.Participant, updates []*model.ParticipantUpdate, batchSize int, audit *model.ParticipantAudit) error {
+	tx := e.db.Begin()
+	defer tx.Rollback()
+
+	var err error
+	if len(inserts) > 0 {
+		err = tx.CreateInBatches(inserts, batchSize).Error
+	}
+
+	for _, update := range updates {
+		if err != nil {
+			break
+		}
+
+		var before, after = model.Participant{}, model.Participant{}
+		err = tx.Table("participants").Where("id = ?", update.ID).First(&before).Error
+		if err == nil {
+			err = tx.Model(&model.Participant{}).Where("id = ?", update.ID).Updates(update.Fields).Error
+		}
+		if err == nil {
+			err = tx.Table("participants").Where("id = ?", update.ID).First(&after).Error
+		}
+		if err == nil && audit != nil {
+			rowAudit := *audit
+			err = writeAudit(tx, &rowAudit, &before, &after)
+		}
+	}
+
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[participant.service.ImportBatch] error execute query %v \n", err)
+		if helper.IsDuplicateKey(err) {
+			return fmt.Errorf("failed insert data: NIK already exists")
+		}
+		return fmt.Errorf("failed insert data: %v", err)
+	}
+
+	tx.Commit()
+
+	return nil
+}
+
 func (s *service) ReadAllReport(criteria map[string]interface{}, date time.Time) ([]*model.Report, error) {
 	var reports []*model.Report
 
-	query := s.db.Table("participants").Select("ROW_NUMBER() OVER (ORDER BY id) AS No", "nik as NIK", "name AS Name", "image_penerima as Image", "phone AS Phone", "address AS Address", "COUNT(*) OVER() AS Total").Where("deleted_at IS NULL").Where(criteria)
+	query := s.db.Table("participants").Select("ROW_NUMBER() OVER (ORDER BY id) AS No", "nik as NIK", "name AS Name", "image_penerima as Image", "phone AS Phone", "address AS Address", handoverColumn, "COUNT(*) OVER() AS Total").Where("deleted_at IS NULL").Where(criteria)
 	if !date.IsZero() {
 		query.Where("updated_at < ?", date)
 
@@ -292,7 +506,7 @@ func (s *service) ReadAllReport(criteria map[string]interface{}, date time.Time)
 func (s *service) ReadAllReportByRangeDate(criteria map[string]interface{}, startDate, endDate time.Time) ([]*model.Report, error) {
 	var reports []*model.Report
 
-	query := s.db.Table("participants").Select("ROW_NUMBER() OVER (ORDER BY id) AS No", "nik as NIK", "name AS Name", "SUBSTRING(image_penerima, 7) as Image", "phone AS Phone", "address AS Address", "COUNT(*) OVER() AS Total").Where("deleted_at IS NULL").Where(criteria)
+	query := s.db.Table("participants").Select("ROW_NUMBER() OVER (ORDER BY id) AS No", "nik as NIK", "name AS Name", "SUBSTRING(image_penerima, 7) as Image", "phone AS Phone", "address AS Address", handoverColumn, "COUNT(*) OVER() AS Total").Where("deleted_at IS NULL").Where(criteria)
 	if !startDate.IsZero() && !endDate.IsZero() {
 		query.Where("updated_at <= ? AND updated_at >=  ?", endDate, startDate)
 
@@ -312,7 +526,7 @@ func (s *service) ReadAllReportByRangeDate(criteria map[string]interface{}, star
 func (s *service) ReadAllReportByRangeDateV2(criteria map[string]interface{}, startDate, endDate time.Time, page, size int) ([]*model.Report, error) {
 	var reports []*model.Report
 
-	query := s.db.Table("participants").Select("ROW_NUMBER() OVER (ORDER BY id) AS No", "id AS ID", "nik as NIK", "name AS Name", "SUBSTRING(image_penerima, 7) as Image", "phone AS Phone", "address AS Address", "COUNT(*) OVER() AS Total").Where("deleted_at IS NULL").Where(criteria)
+	query := s.db.Table("participants").Select("ROW_NUMBER() OVER (ORDER BY id) AS No", "id AS ID", "nik as NIK", "name AS Name", "SUBSTRING(image_penerima, 7) as Image", "phone AS Phone", "address AS Address", handoverColumn, "COUNT(*) OVER() AS Total").Where("deleted_at IS NULL").Where(criteria)
 	if !startDate.IsZero() && !endDate.IsZero() {
 		query.Where("updated_at <= ? AND updated_at >=  ?", endDate, startDate)
 
@@ -336,7 +550,7 @@ func (s *service) ReadAllReportByRangeDateV3(criteria map[string]interface{}, st
 
 	s.db.Table("participants").Select("id").Where("deleted_at IS NULL").Find(&ids)
 
-	query := s.db.Table("participants").Select("ROW_NUMBER() OVER (ORDER BY id) AS No", "nik as NIK", "name AS Name", "SUBSTRING(image_penerima, 7) as Image", "phone AS Phone", "address AS Address", "COUNT(*) OVER() AS Total").Where("deleted_at IS NULL").Where(criteria)
+	query := s.db.Table("participants").Select("ROW_NUMBER() OVER (ORDER BY id) AS No", "nik as NIK", "name AS Name", "SUBSTRING(image_penerima, 7) as Image", "phone AS Phone", "address AS Address", handoverColumn, "COUNT(*) OVER() AS Total").Where("deleted_at IS NULL").Where(criteria)
 	query.Where("id IN (?)", ids)
 	if !startDate.IsZero() && !endDate.IsZero() {
 		query.Where("updated_at <= ? AND updated_at >=  ?", endDate, startDate)
@@ -383,6 +597,41 @@ func (s *service) CreateLog(m *model.ImportLog) (*model.ImportLog, error) {
 	return m, nil
 }
 
+func (s *service) ReadLogById(id int) (*model.ImportLog, error) {
+	var importLog = model.ImportLog{}
+	err := s.db.Where("id = ?", id).First(&importLog).Error
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[participant.service.ReadLogById] error execute query %v \n", err)
+		return nil, fmt.Errorf("id is not exists")
+	}
+	return &importLog, nil
+}
+
+func (s *service) UpdateLog(id int, fields map[string]interface{}) error {
+	err := s.db.Model(&model.ImportLog{}).Where("id = ?", id).Updates(fields).Error
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[participant.service.UpdateLog] error execute query %v \n", err)
+		return fmt.Errorf("failed update data")
+	}
+	return nil
+}
+
+// FailProcessingLogs marks every PROCESSING import FAILED with the message and returns how many there were
+func (s *service) FailProcessingLogs(message string) (int64, error) {
+	result := s.db.Model(&model.ImportLog{}).Where("status = ?", model.IMPORT_PROCESSING).Updates(map[string]interface{}{
+		"status": model.IMPORT_FAILED,
+		"error":  message,
+	})
+	if result.Error != nil {
+		helper.CommonLogger().Error(result.Error)
+		fmt.Printf("[participant.service.FailProcessingLogs] error execute query %v \n", result.Error)
+		return 0, fmt.Errorf("failed update data")
+	}
+	return result.RowsAffected, nil
+}
+
 func (s *service) CountAllStatus(criteria map[string]interface{}) (*model.TotalParticipantResponse, error) {
 	var totalData = model.TotalParticipantResponse{}
 
@@ -425,27 +674,99 @@ func (s *service) CountAllStatusGroup(criteria map[string]interface{}) ([]*model
 
 }
 
-func (s *service) Reset(id int) (*model.Participant, error) {
+// CountAgeGroups counts the participants per model.AgeGroups of their age today
+func (s *service) CountAgeGroups(criteria map[string]interface{}) ([]*model.AgeGroupCount, error) {
+	var list []*model.AgeGroupCount
+
+	var cases strings.Builder
+	var args []interface{}
+	cases.WriteString("CASE WHEN birth_date IS NULL THEN ?")
+	args = append(args, model.AGE_GROUP_UNKNOWN)
+	for _, group := range model.AgeGroups {
+		if group.Max > 0 {
+			cases.WriteString(" WHEN TIMESTAMPDIFF(YEAR, birth_date, CURDATE()) BETWEEN ? AND ? THEN ?")
+			args = append(args, group.Min, group.Max, group.Label)
+		} else {
+			cases.WriteString(" WHEN TIMESTAMPDIFF(YEAR, birth_date, CURDATE()) >= ? THEN ?")
+			args = append(args, group.Min, group.Label)
+		}
+	}
+	cases.WriteString(" ELSE ? END")
+	args = append(args, model.AGE_GROUP_UNKNOWN)
+
+	err := s.db.Table("participants").Select(cases.String()+" AS age_group, COUNT(*) AS total", args...).Where("deleted_at IS NULL").
+		Where(criteria).Group("age_group").Find(&list).Error
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[participant.service.CountAgeGroups] error execute query %v \n", err)
+		return nil, fmt.Errorf("failed view all data")
+	}
+
+	return list, nil
+}
+
+func (s *service) Reset(id int, audit *model.ParticipantAudit) (*model.Participant, error) {
+	tx := s.db.Begin()
+	defer tx.Rollback()
+
+	var before = model.Participant{}
+	err := tx.Table("participants").Where("id = ?", id).First(&before).Error
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[participant.service.Reset] error execute query %v \n", err)
+		return nil, fmt.Errorf("failed update data")
+	}
+
 	var upParticipant = model.Participant{}
-	err := s.db.Table("participants").Where("id = ?", id).First(&upParticipant).Updates(map[string]interface{}{
-		"status": "NOT DONE",
+	err = tx.Table("participants").Where("id = ?", id).First(&upParticipant).Updates(map[string]interface{}{
+		"status": string(model.STATUS_NOT_DONE),
 	}).Error
 	if err != nil {
 		helper.CommonLogger().Error(err)
 		fmt.Printf("[participant.service.Reset] error execute query %v \n", err)
 		return nil, fmt.Errorf("failed update data")
 	}
+
+	var after = model.Participant{}
+	err = tx.Table("participants").Where("id = ?", id).First(&after).Error
+	if err == nil {
+		err = writeAudit(tx, audit, &before, &after)
+	}
+	if err == nil {
+		err = writeStatusEvent(tx, nil, audit, &before, &after)
+	}
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[participant.service.Reset] error execute query %v \n", err)
+		return nil, fmt.Errorf("failed update data")
+	}
+
+	tx.Commit()
+
 	return &upParticipant, nil
 }
 
-func (s *service) Delete(id int) error {
+func (s *service) Delete(id int, audit *model.ParticipantAudit) error {
+	tx := s.db.Begin()
+	defer tx.Rollback()
+
 	var participant = model.Participant{}
-	err := s.db.Table("participants").Where("id = ?", id).First(&participant).Delete(&participant).Error
+	err := tx.Table("participants").Where("id = ?", id).First(&participant).Delete(&participant).Error
 	if err != nil {
 		helper.CommonLogger().Error(err)
 		fmt.Printf("[participant.service.Delete] error execute query %v \n", err)
 		return fmt.Errorf("id is not exists")
 	}
+
+	err = writeAudit(tx, audit, &participant, nil)
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[participant.service.Delete] error execute query %v \n", err)
+		return fmt.Errorf("failed delete data")
+	}
+
+	tx.Commit()
+
 	return nil
 }
 
@@ -0,0 +1,66 @@
+/*
+ * Created on 18/10/26 18.05
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package participant
+
+import (
+	"bumn-sembako-be/helper"
+	"bumn-sembako-be/model"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// handoverColumn is the time the participant became DONE, participants finished before the timeline
+// existed have no event so their last update time is used instead
+const handoverColumn = "COALESCE((SELECT MAX(e.created_at) FROM participant_status_events e WHERE e.participant_id = participants.id AND e.to_status = 'DONE'), updated_at) AS Handover"
+
+// writeStatusEvent appends a timeline step when the status changed, a nil event is built from the audit actor
+// and like writeAudit nothing is recorded when both are nil
+func writeStatusEvent(tx *gorm.DB, event *model.ParticipantStatusEvent, audit *model.ParticipantAudit, before, after *model.Participant) error {
+	if after == nil || (event == nil && audit == nil) {
+		return nil
+	}
+
+	fromStatus := ""
+	if before != nil {
+		fromStatus = before.Status
+	}
+
+	if fromStatus == after.Status {
+		return nil
+	}
+
+	if event == nil {
+		event = &model.ParticipantStatusEvent{}
+	}
+
+	if audit != nil {
+		event.OfficerID = audit.ActorID
+		event.Officer = audit.Actor
+		if event.Reason == "" {
+			event.Reason = audit.Reason
+		}
+	}
+
+	event.ParticipantID = after.ID
+	event.FromStatus = fromStatus
+	event.ToStatus = after.Status
+
+	return tx.Create(event).Error
+}
+
+func (s *service) ReadAllStatusEventBy(criteria map[string]interface{}) ([]*model.ParticipantStatusEvent, error) {
+	var events []*model.ParticipantStatusEvent
+
+	err := s.db.Where(criteria).Order("created_at ASC, id ASC").Find(&events).Error
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[participant.service.ReadAllStatusEventBy] error execute query %v \n", err)
+		return nil, fmt.Errorf("failed view all data")
+	}
+	return events, nil
+}
@@ -0,0 +1,56 @@
+/*
+ * Created on 20/10/26 10.15
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package participant
+
+import (
+	"bumn-sembako-be/helper"
+	"bumn-sembako-be/model"
+	"fmt"
+	"time"
+)
+
+func (s *service) ReadAllValidationRuleBy(criteria map[string]interface{}) ([]*model.ValidationRule, error) {
+	var rules []*model.ValidationRule
+	err := s.db.Where(criteria).Where("deleted_at IS NULL").Order("type ASC, id ASC").Find(&rules).Error
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[participant.service.ReadAllValidationRuleBy] error execute query %v \n", err)
+		return nil, fmt.Errorf("failed view all data")
+	}
+	return rules, nil
+}
+
+func (s *service) ReadValidationRuleById(id int) (*model.ValidationRule, error) {
+	var rule = model.ValidationRule{}
+	err := s.db.Where("id = ?", id).Where("deleted_at IS NULL").First(&rule).Error
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[participant.service.ReadValidationRuleById] error execute query %v \n", err)
+		return nil, fmt.Errorf("id is not exists")
+	}
+	return &rule, nil
+}
+
+func (s *service) SaveValidationRule(rule *model.ValidationRule) (*model.ValidationRule, error) {
+	err := s.db.Save(rule).Error
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[participant.service.SaveValidationRule] error execute query %v \n", err)
+		return nil, fmt.Errorf("failed insert data")
+	}
+	return rule, nil
+}
+
+func (s *service) DeleteValidationRule(id int) error {
+	err := s.db.Model(&model.ValidationRule{}).Where("id = ?", id).Update("deleted_at", time.Now()).Error
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[participant.service.DeleteValidationRule] error execute query %v \n", err)
+		return fmt.Errorf("failed delete data")
+	}
+	return nil
+}
@@ -0,0 +1,298 @@
+/*
+ * Created on 18/10/26 20.02
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package region
+
+import (
+	"bumn-sembako-be/helper"
+	"bumn-sembako-be/model"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// the errors of a region id missing from the cache, a failed cache load is reported as it is
+var ErrProvinceNotFound = errors.New("province is not exists")
+var ErrRegencyNotFound = errors.New("city is not exists")
+
+// cache keeps the whole region dictionary in memory, it is loaded on first use and replaced as a whole on refresh
+type cache struct {
+	provinces []*model.Province
+	regencies []*model.Regency
+	districts []*model.District
+	villages  []*model.Village
+
+	provinceByName  map[string]*model.Province
+	regencyByName   map[string][]*model.Regency
+	districtsByName map[string][]*model.District
+	villagesByName  map[string][]*model.Village
+
+	provinceByID map[int]*model.Province
+	regencyByID  map[int]*model.Regency
+
+	regenciesByProvince map[int][]*model.Regency
+	districtsByRegency  map[int][]*model.District
+	villagesByDistrict  map[int][]*model.Village
+}
+
+func cacheKey(name string) string {
+	return strings.ToUpper(strings.TrimSpace(name))
+}
+
+func (s *service) RefreshCache() error {
+	var provinces []*model.Province
+	var regencies []*model.Regency
+	var districts []*model.District
+	var villages []*model.Village
+
+	err := s.db.Order("created_at ASC").Find(&provinces).Error
+	if err == nil {
+		err = s.db.Order("created_at ASC").Find(&regencies).Error
+	}
+	if err == nil {
+		err = s.db.Order("created_at ASC").Find(&districts).Error
+	}
+	if err == nil {
+		err = s.db.Order("created_at ASC").Find(&villages).Error
+	}
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[region.service.RefreshCache] error execute query %v \n", err)
+		return fmt.Errorf("failed load region")
+	}
+
+	c := newCache(provinces, regencies, districts, villages)
+
+	s.mu.Lock()
+	s.cache = c
+	s.mu.Unlock()
+
+	return nil
+}
+
+// newCache indexes the region dictionary by name, id and parent
+func newCache(provinces []*model.Province, regencies []*model.Regency, districts []*model.District, villages []*model.Village) *cache {
+	c := &cache{
+		provinces:           provinces,
+		regencies:           regencies,
+		districts:           districts,
+		villages:            villages,
+		provinceByName:      make(map[string]*model.Province),
+		regencyByName:       make(map[string][]*model.Regency),
+		districtsByName:     make(map[string][]*model.District),
+		villagesByName:      make(map[string][]*model.Village),
+		provinceByID:        make(map[int]*model.Province),
+		regencyByID:         make(map[int]*model.Regency),
+		regenciesByProvince: make(map[int][]*model.Regency),
+		districtsByRegency:  make(map[int][]*model.District),
+		villagesByDistrict:  make(map[int][]*model.Village),
+	}
+
+	for _, province := range provinces {
+		c.provinceByName[cacheKey(province.Name)] = province
+		c.provinceByID[province.ID] = province
+	}
+
+	for _, regency := range regencies {
+		key := cacheKey(regency.Name)
+		c.regencyByName[key] = append(c.regencyByName[key], regency)
+		c.regencyByID[regency.ID] = regency
+		c.regenciesByProvince[int(regency.ProvinceID)] = append(c.regenciesByProvince[int(regency.ProvinceID)], regency)
+	}
+
+	for _, district := range districts {
+		key := cacheKey(district.Name)
+		c.districtsByName[key] = append(c.districtsByName[key], district)
+		c.districtsByRegency[int(district.RegencyID)] = append(c.districtsByRegency[int(district.RegencyID)], district)
+	}
+
+	for _, village := range villages {
+		key := cacheKey(village.Name)
+		c.villagesByName[key] = append(c.villagesByName[key], village)
+		c.villagesByDistrict[int(village.DistrictID)] = append(c.villagesByDistrict[int(village.DistrictID)], village)
+	}
+
+	return c
+}
+
+// loadCache returns the current dictionary, loading it when nothing has been cached yet
+func (s *service) loadCache() (*cache, error) {
+	s.mu.RLock()
+	c := s.cache
+	s.mu.RUnlock()
+	if c != nil {
+		return c, nil
+	}
+
+	s.loadMu.Lock()
+	defer s.loadMu.Unlock()
+
+	s.mu.RLock()
+	c = s.cache
+	s.mu.RUnlock()
+	if c != nil {
+		return c, nil
+	}
+
+	err := s.RefreshCache()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cache, nil
+}
+
+func (s *service) CachedProvinces(search string) ([]*model.Province, error) {
+	c, err := s.loadCache()
+	if err != nil {
+		return nil, err
+	}
+
+	provinces := make([]*model.Province, 0)
+	for _, province := range c.provinces {
+		if hasPrefix(province.Name, search) {
+			provinces = append(provinces, province)
+		}
+	}
+	return provinces, nil
+}
+
+func (s *service) CachedRegencies(provinceID int, search string) ([]*model.Regency, error) {
+	c, err := s.loadCache()
+	if err != nil {
+		return nil, err
+	}
+
+	regencies := make([]*model.Regency, 0)
+	for _, regency := range c.regenciesByProvince[provinceID] {
+		if hasPrefix(regency.Name, search) {
+			regencies = append(regencies, regency)
+		}
+	}
+	return regencies, nil
+}
+
+func (s *service) CachedDistricts(regencyID int, search string) ([]*model.District, error) {
+	c, err := s.loadCache()
+	if err != nil {
+		return nil, err
+	}
+
+	districts := make([]*model.District, 0)
+	for _, district := range c.districtsByRegency[regencyID] {
+		if hasPrefix(district.Name, search) {
+			districts = append(districts, district)
+		}
+	}
+	return districts, nil
+}
+
+func (s *service) CachedVillages(districtID int, search string) ([]*model.Village, error) {
+	c, err := s.loadCache()
+	if err != nil {
+		return nil, err
+	}
+
+	villages := make([]*model.Village, 0)
+	for _, village := range c.villagesByDistrict[districtID] {
+		if hasPrefix(village.Name, search) {
+			villages = append(villages, village)
+		}
+	}
+	return villages, nil
+}
+
+func (s *service) FindProvince(name string) (*model.Province, error) {
+	c, err := s.loadCache()
+	if err != nil {
+		return nil, err
+	}
+
+	province, ok := c.provinceByName[cacheKey(name)]
+	if !ok {
+		return nil, fmt.Errorf("province is not exists")
+	}
+	return province, nil
+}
+
+// FindRegency looks the regency up by name, a provinceID of 0 accepts a regency of any province
+func (s *service) FindRegency(provinceID int, name string) (*model.Regency, error) {
+	c, err := s.loadCache()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, regency := range c.regencyByName[cacheKey(name)] {
+		if provinceID == 0 || int(regency.ProvinceID) == provinceID {
+			return regency, nil
+		}
+	}
+	return nil, fmt.Errorf("city is not exists")
+}
+
+// FindDistrict looks the district up by name, a regencyID of 0 accepts a district of any regency
+func (s *service) FindDistrict(regencyID int, name string) (*model.District, error) {
+	c, err := s.loadCache()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, district := range c.districtsByName[cacheKey(name)] {
+		if regencyID == 0 || int(district.RegencyID) == regencyID {
+			return district, nil
+		}
+	}
+	return nil, fmt.Errorf("district is not exists")
+}
+
+// FindVillage looks the village up by name, a districtID of 0 accepts a village of any district
+func (s *service) FindVillage(districtID int, name string) (*model.Village, error) {
+	c, err := s.loadCache()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, village := range c.villagesByName[cacheKey(name)] {
+		if districtID == 0 || int(village.DistrictID) == districtID {
+			return village, nil
+		}
+	}
+	return nil, fmt.Errorf("village is not exists")
+}
+
+// FindProvinceByID looks the province up by id, the ids are the Kemendagri codes also used in the NIK
+func (s *service) FindProvinceByID(id int) (*model.Province, error) {
+	c, err := s.loadCache()
+	if err != nil {
+		return nil, err
+	}
+
+	province, ok := c.provinceByID[id]
+	if !ok {
+		return nil, ErrProvinceNotFound
+	}
+	return province, nil
+}
+
+// FindRegencyByID looks the regency up by id, the ids are the Kemendagri codes also used in the NIK
+func (s *service) FindRegencyByID(id int) (*model.Regency, error) {
+	c, err := s.loadCache()
+	if err != nil {
+		return nil, err
+	}
+
+	regency, ok := c.regencyByID[id]
+	if !ok {
+		return nil, ErrRegencyNotFound
+	}
+	return regency, nil
+}
+
+func hasPrefix(name, search string) bool {
+	return search == "" || strings.HasPrefix(strings.ToUpper(name), strings.ToUpper(search))
+}
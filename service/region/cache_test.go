@@ -0,0 +1,36 @@
+/*
+ * Created on 21/10/26 16.30
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package region
+
+import (
+	"bumn-sembako-be/model"
+	"errors"
+	"testing"
+)
+
+func TestFindByID(t *testing.T) {
+	s := &service{cache: newCache(
+		[]*model.Province{{ID: 32, Name: "JAWA BARAT"}},
+		[]*model.Regency{{ID: 3201, ProvinceID: 32, Name: "KABUPATEN BOGOR"}},
+		nil,
+		nil,
+	)}
+
+	if province, err := s.FindProvinceByID(32); err != nil || province.Name != "JAWA BARAT" {
+		t.Errorf("FindProvinceByID(32) = %v, %v", province, err)
+	}
+	if _, err := s.FindProvinceByID(99); !errors.Is(err, ErrProvinceNotFound) {
+		t.Errorf("FindProvinceByID(99) error = %v, want %v", err, ErrProvinceNotFound)
+	}
+
+	if regency, err := s.FindRegencyByID(3201); err != nil || regency.Name != "KABUPATEN BOGOR" {
+		t.Errorf("FindRegencyByID(3201) = %v, %v", regency, err)
+	}
+	if _, err := s.FindRegencyByID(3299); !errors.Is(err, ErrRegencyNotFound) {
+		t.Errorf("FindRegencyByID(3299) error = %v, want %v", err, ErrRegencyNotFound)
+	}
+}
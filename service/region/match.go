@@ -0,0 +1,269 @@
+/*
+ * Created on 18/10/26 21.15
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package region
+
+import (
+	"bumn-sembako-be/helper"
+	"bumn-sembako-be/model"
+	"strings"
+	"unicode"
+)
+
+// a fuzzy match at or above matchConfident is corrected automatically,
+// one at or above matchSuggest is only offered as a suggestion
+const matchConfident = 0.85
+const matchSuggest = 0.6
+
+// regionAliases maps common short or informal names to the official province name
+var regionAliases = map[string]string{
+	"DKI":                           "DKI JAKARTA",
+	"JAKARTA":                       "DKI JAKARTA",
+	"DAERAH KHUSUS IBUKOTA JAKARTA": "DKI JAKARTA",
+	"DIY":                           "DI YOGYAKARTA",
+	"YOGYAKARTA":                    "DI YOGYAKARTA",
+	"JOGJA":                         "DI YOGYAKARTA",
+	"JOGJAKARTA":                    "DI YOGYAKARTA",
+	"DAERAH ISTIMEWA YOGYAKARTA":    "DI YOGYAKARTA",
+	"NAD":                           "ACEH",
+	"NANGGROE ACEH DARUSSALAM":      "ACEH",
+	"JABAR":                         "JAWA BARAT",
+	"JATENG":                        "JAWA TENGAH",
+	"JATIM":                         "JAWA TIMUR",
+	"SUMUT":                         "SUMATERA UTARA",
+	"SUMBAR":                        "SUMATERA BARAT",
+	"SUMSEL":                        "SUMATERA SELATAN",
+	"KALBAR":                        "KALIMANTAN BARAT",
+	"KALTENG":                       "KALIMANTAN TENGAH",
+	"KALSEL":                        "KALIMANTAN SELATAN",
+	"KALTIM":                        "KALIMANTAN TIMUR",
+	"KALTARA":                       "KALIMANTAN UTARA",
+	"SULUT":                         "SULAWESI UTARA",
+	"SULTENG":                       "SULAWESI TENGAH",
+	"SULSEL":                        "SULAWESI SELATAN",
+	"SULTRA":                        "SULAWESI TENGGARA",
+	"SULBAR":                        "SULAWESI BARAT",
+	"NTB":                           "NUSA TENGGARA BARAT",
+	"NTT":                           "NUSA TENGGARA TIMUR",
+	"BABEL":                         "KEPULAUAN BANGKA BELITUNG",
+	"BANGKA BELITUNG":               "KEPULAUAN BANGKA BELITUNG",
+	"KEPRI":                         "KEPULAUAN RIAU",
+}
+
+// regionAbbreviations expands abbreviated words, an empty value drops words that only name the region level
+var regionAbbreviations = map[string]string{
+	"KAB":       "KABUPATEN",
+	"ADM":       "ADMINISTRASI",
+	"KEP":       "KEPULAUAN",
+	"PROV":      "",
+	"PROVINSI":  "",
+	"KEC":       "",
+	"KECAMATAN": "",
+	"KEL":       "",
+	"KELURAHAN": "",
+	"DESA":      "",
+	"DS":        "",
+}
+
+// regionKinds are the regency prefixes, KOTA ADMINISTRASI must be checked before KOTA
+var regionKinds = []string{"KABUPATEN ", "KOTA ADMINISTRASI ", "KOTA "}
+
+func normalizeRegion(name string) string {
+	cleaned := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToUpper(r)
+		}
+		return ' '
+	}, name)
+
+	var words []string
+	for _, word := range strings.Fields(cleaned) {
+		if expanded, ok := regionAbbreviations[word]; ok {
+			if expanded == "" {
+				continue
+			}
+			word = expanded
+		}
+		words = append(words, word)
+	}
+
+	normalized := strings.Join(words, " ")
+	if alias, ok := regionAliases[normalized]; ok {
+		return alias
+	}
+	return normalized
+}
+
+// splitKind separates the regency kind from the name, "KABUPATEN BOGOR" and "KOTA BOGOR" share the core "BOGOR"
+func splitKind(normalized string) (string, string) {
+	for _, kind := range regionKinds {
+		if strings.HasPrefix(normalized, kind) {
+			return strings.TrimSpace(kind), strings.TrimPrefix(normalized, kind)
+		}
+	}
+	return "", normalized
+}
+
+// matchName resolves name against the candidates, it returns the index of the matched candidate
+// or -1 with a suggestion when the match is not confident enough to be corrected automatically
+func matchName(name string, candidates []string) (int, string) {
+	key := cacheKey(name)
+	for i, candidate := range candidates {
+		if cacheKey(candidate) == key {
+			return i, ""
+		}
+	}
+
+	normalized := normalizeRegion(name)
+	kind, core := splitKind(normalized)
+	if core == "" {
+		return -1, ""
+	}
+
+	var sameCore []int
+	for i, candidate := range candidates {
+		candidateNormalized := normalizeRegion(candidate)
+		if candidateNormalized == normalized {
+			return i, ""
+		}
+
+		candidateKind, candidateCore := splitKind(candidateNormalized)
+		if candidateCore == core && (kind == "" || kind == candidateKind) {
+			sameCore = append(sameCore, i)
+		}
+	}
+
+	if len(sameCore) == 1 {
+		return sameCore[0], ""
+	}
+
+	if len(sameCore) > 1 {
+		var names []string
+		for _, i := range sameCore {
+			names = append(names, candidates[i])
+		}
+		return -1, strings.Join(names, " atau ")
+	}
+
+	best, bestScore, secondScore := -1, 0.0, 0.0
+	for i, candidate := range candidates {
+		candidateKind, candidateCore := splitKind(normalizeRegion(candidate))
+		if kind != "" && candidateKind != "" && kind != candidateKind {
+			continue
+		}
+
+		score := similarity(core, candidateCore)
+		if score > bestScore {
+			best, bestScore, secondScore = i, score, bestScore
+		} else if score > secondScore {
+			secondScore = score
+		}
+	}
+
+	if best < 0 || bestScore < matchSuggest {
+		return -1, ""
+	}
+
+	if bestScore >= matchConfident && bestScore > secondScore {
+		return best, ""
+	}
+
+	return -1, candidates[best]
+}
+
+func similarity(a, b string) float64 {
+	longest := len([]rune(a))
+	if l := len([]rune(b)); l > longest {
+		longest = l
+	}
+	if longest == 0 {
+		return 0
+	}
+	return 1 - float64(helper.Levenshtein(a, b))/float64(longest)
+}
+
+// MatchProvince returns the province the name resolves to, or a suggestion when it cannot be resolved with confidence
+func (s *service) MatchProvince(name string) (*model.Province, string) {
+	c, err := s.loadCache()
+	if err != nil {
+		return nil, ""
+	}
+
+	if province, ok := c.provinceByName[cacheKey(name)]; ok {
+		return province, ""
+	}
+
+	names := make([]string, len(c.provinces))
+	for i, province := range c.provinces {
+		names[i] = province.Name
+	}
+
+	i, suggestion := matchName(name, names)
+	if i < 0 {
+		return nil, suggestion
+	}
+	return c.provinces[i], ""
+}
+
+// MatchRegency is MatchProvince for the regencies of a province
+func (s *service) MatchRegency(provinceID int, name string) (*model.Regency, string) {
+	c, err := s.loadCache()
+	if err != nil {
+		return nil, ""
+	}
+
+	regencies := c.regenciesByProvince[provinceID]
+	names := make([]string, len(regencies))
+	for i, regency := range regencies {
+		names[i] = regency.Name
+	}
+
+	i, suggestion := matchName(name, names)
+	if i < 0 {
+		return nil, suggestion
+	}
+	return regencies[i], ""
+}
+
+// MatchDistrict is MatchProvince for the districts of a regency
+func (s *service) MatchDistrict(regencyID int, name string) (*model.District, string) {
+	c, err := s.loadCache()
+	if err != nil {
+		return nil, ""
+	}
+
+	districts := c.districtsByRegency[regencyID]
+	names := make([]string, len(districts))
+	for i, district := range districts {
+		names[i] = district.Name
+	}
+
+	i, suggestion := matchName(name, names)
+	if i < 0 {
+		return nil, suggestion
+	}
+	return districts[i], ""
+}
+
+// MatchVillage is MatchProvince for the villages of a district
+func (s *service) MatchVillage(districtID int, name string) (*model.Village, string) {
+	c, err := s.loadCache()
+	if err != nil {
+		return nil, ""
+	}
+
+	villages := c.villagesByDistrict[districtID]
+	names := make([]string, len(villages))
+	for i, village := range villages {
+		names[i] = village.Name
+	}
+
+	i, suggestion := matchName(name, names)
+	if i < 0 {
+		return nil, suggestion
+	}
+	return villages[i], ""
+}
@@ -10,6 +10,7 @@ import (
 	"bumn-sembako-be/helper"
 	"bumn-sembako-be/model"
 	"fmt"
+	"sync"
 
 	"gorm.io/gorm"
 )
@@ -23,10 +24,29 @@ type Service interface {
 	ReadDistrictBy(criteria map[string]interface{}) (*model.District, error)
 	ReadAllVillageBy(criteria map[string]interface{}, search string) ([]*model.Village, error)
 	ReadVillageBy(criteria map[string]interface{}) (*model.Village, error)
+	RefreshCache() error
+	CachedProvinces(search string) ([]*model.Province, error)
+	CachedRegencies(provinceID int, search string) ([]*model.Regency, error)
+	CachedDistricts(regencyID int, search string) ([]*model.District, error)
+	CachedVillages(districtID int, search string) ([]*model.Village, error)
+	FindProvince(name string) (*model.Province, error)
+	FindRegency(provinceID int, name string) (*model.Regency, error)
+	FindDistrict(regencyID int, name string) (*model.District, error)
+	FindVillage(districtID int, name string) (*model.Village, error)
+	FindProvinceByID(id int) (*model.Province, error)
+	FindRegencyByID(id int) (*model.Regency, error)
+	MatchProvince(name string) (*model.Province, string)
+	MatchRegency(provinceID int, name string) (*model.Regency, string)
+	MatchDistrict(regencyID int, name string) (*model.District, string)
+	MatchVillage(districtID int, name string) (*model.Village, string)
 }
 
 type service struct {
 	db *gorm.DB
+
+	mu     sync.RWMutex
+	loadMu sync.Mutex
+	cache  *cache
 }
 
 func NewService(db *gorm.DB) Service {
@@ -11,6 +11,7 @@ import (
 	"bumn-sembako-be/model"
 	"bumn-sembako-be/request"
 	"fmt"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -21,7 +22,21 @@ type Service interface {
 	ReadByUsername(username string) (*model.User, error)
 	CheckByUsername(username string) (*model.User, error)
 	Update(id int, user *request.User) (*model.User, error)
+	UpdateLoginAttempt(id int, retryAttempts int64, lockedUntil *time.Time) error
+	FailLoginAttempt(id int, maxAttempts int64, lockedUntil time.Time) (int64, bool, error)
+	UpdatePassword(id int, password string, mustChangePassword bool) error
+	ResetPassword(id int, password string) error
+	CreatePasswordHistory(userID int, password string) error
+	ReadPasswordHistory(userID int, limit int) ([]model.PasswordHistory, error)
 	Delete(id int) error
+	CreateSession(session *model.Session) (*model.Session, error)
+	ReadSessionById(id int) (*model.Session, error)
+	ReadSessionByRefreshToken(hash string) (*model.Session, error)
+	ReadSessionByPreviousRefreshToken(hash string) (*model.Session, error)
+	ReadActiveSessions(userID int) ([]model.Session, error)
+	RotateSession(id int, oldHash, hash string, expiresAt time.Time) (bool, error)
+	RevokeSession(id int) error
+	RevokeUserSessions(userID int) error
 	Count(criteria map[string]interface{}) int64
 	ReadAllBy(criteria map[string]interface{}, search string, page, size int) (*[]model.User, error)
 	ReadAllOrganization() (*[]model.Organization, error)
@@ -96,6 +111,148 @@ func (e *service) Update(id int, user *request.User) (*model.User, error) {
 	return &upUser, nil
 }
 
+// UpdateLoginAttempt uses a map so that resetting the counter to zero and unlocking are persisted
+func (e *service) UpdateLoginAttempt(id int, retryAttempts int64, lockedUntil *time.Time) error {
+	err := e.db.Table("users").Where("id = ?", id).Updates(map[string]interface{}{
+		"retry_attempts": retryAttempts,
+		"locked_until":   lockedUntil,
+	}).Error
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[user.service.UpdateLoginAttempt] error execute query %v \n", err)
+		return fmt.Errorf("failed update data")
+	}
+	return nil
+}
+
+// FailLoginAttempt counts a failed login in SQL so concurrent failures are not lost, the account is locked
+// when the counter it reads back reaches maxAttempts. It returns the counter and whether the account got locked
+func (e *service) FailLoginAttempt(id int, maxAttempts int64, lockedUntil time.Time) (int64, bool, error) {
+	tx := e.db.Begin()
+	defer tx.Rollback()
+
+	err := tx.Table("users").Where("id = ?", id).Update("retry_attempts", gorm.Expr("retry_attempts + 1")).Error
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[user.service.FailLoginAttempt] error execute query %v \n", err)
+		return 0, false, fmt.Errorf("failed update data")
+	}
+
+	var attempts int64
+	err = tx.Table("users").Where("id = ?", id).Select("retry_attempts").Scan(&attempts).Error
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[user.service.FailLoginAttempt] error execute query %v \n", err)
+		return 0, false, fmt.Errorf("failed update data")
+	}
+
+	locked := attempts >= maxAttempts
+	if locked {
+		err = tx.Table("users").Where("id = ?", id).Updates(map[string]interface{}{
+			"retry_attempts": 0,
+			"locked_until":   lockedUntil,
+		}).Error
+		if err != nil {
+			helper.CommonLogger().Error(err)
+			fmt.Printf("[user.service.FailLoginAttempt] error execute query %v \n", err)
+			return 0, false, fmt.Errorf("failed update data")
+		}
+	}
+
+	tx.Commit()
+
+	return attempts, locked, nil
+}
+
+// UpdatePassword stores the new hash and its history entry in one transaction
+func (e *service) UpdatePassword(id int, password string, mustChangePassword bool) error {
+	tx := e.db.Begin()
+	defer tx.Rollback()
+
+	now := time.Now()
+	err := tx.Table("users").Where("id = ?", id).Updates(map[string]interface{}{
+		"password":             password,
+		"must_change_password": mustChangePassword,
+		"password_changed_at":  now,
+	}).Error
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[user.service.UpdatePassword] error execute query %v \n", err)
+		return fmt.Errorf("failed update data")
+	}
+
+	err = tx.Create(&model.PasswordHistory{UserID: id, Password: password}).Error
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[user.service.UpdatePassword] error execute query %v \n", err)
+		return fmt.Errorf("failed update data")
+	}
+
+	tx.Commit()
+
+	return nil
+}
+
+// ResetPassword stores the one-time password that has to be changed, unlocks the account and revokes every
+// session of the user in one transaction
+func (e *service) ResetPassword(id int, password string) error {
+	tx := e.db.Begin()
+	defer tx.Rollback()
+
+	now := time.Now()
+	err := tx.Table("users").Where("id = ?", id).Updates(map[string]interface{}{
+		"password":             password,
+		"must_change_password": true,
+		"password_changed_at":  now,
+		"retry_attempts":       0,
+		"locked_until":         nil,
+	}).Error
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[user.service.ResetPassword] error execute query %v \n", err)
+		return fmt.Errorf("failed update data")
+	}
+
+	err = tx.Create(&model.PasswordHistory{UserID: id, Password: password}).Error
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[user.service.ResetPassword] error execute query %v \n", err)
+		return fmt.Errorf("failed update data")
+	}
+
+	err = tx.Model(&model.Session{}).Where("user_id = ? AND revoked_at IS NULL", id).Update("revoked_at", now).Error
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[user.service.ResetPassword] error execute query %v \n", err)
+		return fmt.Errorf("failed update data")
+	}
+
+	tx.Commit()
+
+	return nil
+}
+
+func (e *service) CreatePasswordHistory(userID int, password string) error {
+	err := e.db.Create(&model.PasswordHistory{UserID: userID, Password: password}).Error
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[user.service.CreatePasswordHistory] error execute query %v \n", err)
+		return fmt.Errorf("failed insert data")
+	}
+	return nil
+}
+
+func (e *service) ReadPasswordHistory(userID int, limit int) ([]model.PasswordHistory, error) {
+	var histories []model.PasswordHistory
+	err := e.db.Where("user_id = ?", userID).Order("created_at DESC").Limit(limit).Find(&histories).Error
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[user.service.ReadPasswordHistory] error execute query %v \n", err)
+		return nil, fmt.Errorf("failed view all data")
+	}
+	return histories, nil
+}
+
 func (e *service) ReadByUsername(username string) (*model.User, error) {
 	var user = model.User{}
 	err := e.db.Table("users").Where("username = ?", username).First(&user).Error
@@ -118,14 +275,123 @@ func (e *service) CheckByUsername(username string) (*model.User, error) {
 	return &user, nil
 }
 
+// Delete also revokes every session of the user in the same transaction so issued tokens stop working immediately
 func (e *service) Delete(id int) error {
+	tx := e.db.Begin()
+	defer tx.Rollback()
+
 	var user = model.User{}
-	err := e.db.Table("users").Where("id = ?", id).First(&user).Delete(&user).Error
+	err := tx.Table("users").Where("id = ?", id).First(&user).Delete(&user).Error
 	if err != nil {
 		helper.CommonLogger().Error(err)
 		fmt.Printf("[user.service.Delete] error execute query %v \n", err)
 		return fmt.Errorf("id is not exists")
 	}
+
+	err = tx.Model(&model.Session{}).Where("user_id = ? AND revoked_at IS NULL", id).Update("revoked_at", time.Now()).Error
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[user.service.Delete] error execute query %v \n", err)
+		return fmt.Errorf("failed delete data")
+	}
+
+	tx.Commit()
+
+	return nil
+}
+
+func (e *service) CreateSession(session *model.Session) (*model.Session, error) {
+	err := e.db.Create(session).Error
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[user.service.CreateSession] error execute query %v \n", err)
+		return nil, fmt.Errorf("failed insert data")
+	}
+	return session, nil
+}
+
+func (e *service) ReadSessionById(id int) (*model.Session, error) {
+	var session = model.Session{}
+	err := e.db.Where("id = ?", id).First(&session).Error
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[user.service.ReadSessionById] error execute query %v \n", err)
+		return nil, fmt.Errorf("session is not exists")
+	}
+	return &session, nil
+}
+
+func (e *service) ReadSessionByRefreshToken(hash string) (*model.Session, error) {
+	var session = model.Session{}
+	err := e.db.Where("refresh_token_hash = ?", hash).First(&session).Error
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[user.service.ReadSessionByRefreshToken] error execute query %v \n", err)
+		return nil, fmt.Errorf("session is not exists")
+	}
+	return &session, nil
+}
+
+// ReadSessionByPreviousRefreshToken finds the session a refresh token was rotated out of
+func (e *service) ReadSessionByPreviousRefreshToken(hash string) (*model.Session, error) {
+	var session = model.Session{}
+	err := e.db.Where("previous_refresh_token_hash = ?", hash).First(&session).Error
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[user.service.ReadSessionByPreviousRefreshToken] error execute query %v \n", err)
+		return nil, fmt.Errorf("session is not exists")
+	}
+	return &session, nil
+}
+
+func (e *service) ReadActiveSessions(userID int) ([]model.Session, error) {
+	var sessions []model.Session
+	err := e.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).Find(&sessions).Error
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[user.service.ReadActiveSessions] error execute query %v \n", err)
+		return nil, fmt.Errorf("failed view all data")
+	}
+	return sessions, nil
+}
+
+// RotateSession replaces the refresh token so a used refresh token can not be replayed. Only the session still
+// holding oldHash is rotated, false tells the token was already rotated or revoked by a concurrent request.
+// oldHash is kept as the previous refresh token so a later replay of it is recognised
+func (e *service) RotateSession(id int, oldHash, hash string, expiresAt time.Time) (bool, error) {
+	result := e.db.Model(&model.Session{}).
+		Where("id = ? AND refresh_token_hash = ? AND revoked_at IS NULL", id, oldHash).
+		Updates(map[string]interface{}{
+			"refresh_token_hash":          hash,
+			"previous_refresh_token_hash": oldHash,
+			"expires_at":                  expiresAt,
+			"last_used_at":                time.Now(),
+		})
+	if result.Error != nil {
+		helper.CommonLogger().Error(result.Error)
+		fmt.Printf("[user.service.RotateSession] error execute query %v \n", result.Error)
+		return false, fmt.Errorf("failed update data")
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (e *service) RevokeSession(id int) error {
+	err := e.db.Model(&model.Session{}).Where("id = ? AND revoked_at IS NULL", id).Update("revoked_at", time.Now()).Error
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[user.service.RevokeSession] error execute query %v \n", err)
+		return fmt.Errorf("failed update data")
+	}
+	return nil
+}
+
+func (e *service) RevokeUserSessions(userID int) error {
+	err := e.db.Model(&model.Session{}).Where("user_id = ? AND revoked_at IS NULL", userID).Update("revoked_at", time.Now()).Error
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		fmt.Printf("[user.service.RevokeUserSessions] error execute query %v \n", err)
+		return fmt.Errorf("failed update data")
+	}
 	return nil
 }
 
@@ -0,0 +1,43 @@
+/*
+ * Created on 18/10/26 16.48
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package participant
+
+import (
+	"bumn-sembako-be/model"
+	"bumn-sembako-be/request"
+)
+
+func newAudit(action string, authUser *model.User, ip string) *model.ParticipantAudit {
+	audit := &model.ParticipantAudit{Action: action, IP: ip}
+	if authUser != nil {
+		audit.ActorID = authUser.ID
+		audit.Actor = authUser.Username
+	}
+	return audit
+}
+
+func (u *usecase) ReadAllHistory(id int, req request.HistoryPaged, authUser *model.User) ([]*model.ParticipantAudit, error) {
+	_, err := u.readInScope(id, authUser)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.service.ReadAllAuditBy(map[string]interface{}{"participant_id": id}, req.Page, req.Size)
+}
+
+func (u *usecase) CountHistory(id int) int64 {
+	return u.service.CountAudits(map[string]interface{}{"participant_id": id})
+}
+
+func (u *usecase) ReadAllStatusEvents(id int, authUser *model.User) ([]*model.ParticipantStatusEvent, error) {
+	_, err := u.readInScope(id, authUser)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.service.ReadAllStatusEventBy(map[string]interface{}{"participant_id": id})
+}
@@ -0,0 +1,1008 @@
+/*
+ * Created on 18/10/26 19.10
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package participant
+
+import (
+	"bumn-sembako-be/helper"
+	"bumn-sembako-be/model"
+	"bumn-sembako-be/request"
+	"bumn-sembako-be/validator"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+var ErrImportNoReference = errors.New("import tidak memiliki referensi peserta")
+var ErrImportNotFinished = errors.New("import masih diproses")
+var ErrImportRolledBack = errors.New("import sudah dibatalkan")
+var ErrImportProcessed = errors.New("import tidak dapat dibatalkan, sebagian peserta sudah diproses")
+var ErrImportNotUploader = errors.New("import hanya dapat dibatalkan oleh pengunggahnya")
+var ErrImportNotViewable = errors.New("import hanya dapat dilihat oleh pengunggahnya")
+var ErrImportTooLarge = errors.New("jumlah baris melebihi batas import all_or_nothing")
+
+const defaultImportWorkers = 4
+const defaultImportBatchSize = 500
+const defaultImportAtomicRows = 10000
+
+// importProgressStep is how many processed rows are buffered before the progress is written to the import log
+const importProgressStep = 100
+
+var importHeaders = []string{
+	"Nama", "NIK", "Jenis Kelamin", "No Handphone", "Alamat Sesuai KTP", "RT", "RW", "Provinsi", "Kota/Kabupaten",
+	"Kecamatan", "Kelurahan", "Kode Pos", "Alamat Domisili", "RT Domisili", "RW Domisili", "Provinsi Domisili",
+	"Kota/Kabupaten Domisili", "Kecamatan Domisili", "Kelurahan Domisili", "Kode Pos Domisili", "Status", "Catatan",
+	"Hasil",
+}
+
+// importColumns are the columns an upsert import may overwrite, the status is left to the status flow
+var importColumns = []struct {
+	name  string
+	value func(participant *model.Participant) string
+}{
+	{"name", func(p *model.Participant) string { return p.Name }},
+	{"gender", func(p *model.Participant) string { return p.Gender }},
+	{"phone", func(p *model.Participant) string { return p.Phone }},
+	{"address", func(p *model.Participant) string { return p.Address }},
+	{"rt", func(p *model.Participant) string { return p.RT }},
+	{"rw", func(p *model.Participant) string { return p.RW }},
+	{"provinsi", func(p *model.Participant) string { return p.Provinsi }},
+	{"kota", func(p *model.Participant) string { return p.Kota }},
+	{"kecamatan", func(p *model.Participant) string { return p.Kecamatan }},
+	{"kelurahan", func(p *model.Participant) string { return p.Kelurahan }},
+	{"kode_pos", func(p *model.Participant) string { return p.KodePOS }},
+	{"residence_address", func(p *model.Participant) string { return p.ResidenceAddress }},
+	{"residence_rt", func(p *model.Participant) string { return p.ResidenceRT }},
+	{"residence_rw", func(p *model.Participant) string { return p.ResidenceRW }},
+	{"residence_provinsi", func(p *model.Participant) string { return p.ResidenceProvinsi }},
+	{"residence_kota", func(p *model.Participant) string { return p.ResidenceKota }},
+	{"residence_kecamatan", func(p *model.Participant) string { return p.ResidenceKecamatan }},
+	{"residence_kelurahan", func(p *model.Participant) string { return p.ResidenceKelurahan }},
+	{"residence_kode_pos", func(p *model.Participant) string { return p.ResidenceKodePOS }},
+}
+
+// importOptions are the settings of one import, shared by every row of the file
+type importOptions struct {
+	mode            string
+	transactionMode string
+	fields          map[string]bool
+	overwriteDone   bool
+	format          string
+	delimiter       rune
+	reference       string
+	participantType string
+	audit           *model.ParticipantAudit
+	validator       *validator.Validator
+
+	// authUser is the uploader, an import only inserts or updates the participants in the region of the uploader
+	authUser *model.User
+}
+
+// importRowOp is what a valid row does to the database, nil for an unchanged row
+type importRowOp struct {
+	insert *model.Participant
+	update *model.ParticipantUpdate
+}
+
+func newImportOptions(req request.ImportParticipant, authUser *model.User) (*importOptions, error) {
+	opts := &importOptions{
+		authUser:        authUser,
+		mode:            req.Mode,
+		transactionMode: req.TransactionMode,
+		fields:          make(map[string]bool),
+		overwriteDone:   req.OverwriteDone,
+		participantType: req.Type,
+	}
+
+	if opts.mode == "" {
+		opts.mode = model.IMPORT_MODE_INSERT
+	}
+	if opts.mode != model.IMPORT_MODE_INSERT && opts.mode != model.IMPORT_MODE_UPSERT {
+		return nil, fmt.Errorf("mode tidak dikenal: %s", req.Mode)
+	}
+
+	if opts.transactionMode == "" {
+		opts.transactionMode = model.IMPORT_MODE_PARTIAL
+	}
+	if opts.transactionMode != model.IMPORT_MODE_PARTIAL && opts.transactionMode != model.IMPORT_MODE_ALL_OR_NOTHING {
+		return nil, fmt.Errorf("transaction_mode tidak dikenal: %s", req.TransactionMode)
+	}
+
+	allowed := make(map[string]bool)
+	for _, column := range importColumns {
+		allowed[column.name] = true
+	}
+
+	for _, field := range strings.Split(req.Fields, ",") {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if field == "" {
+			continue
+		}
+		if !allowed[field] {
+			return nil, fmt.Errorf("field tidak dapat diperbarui lewat import: %s", field)
+		}
+		opts.fields[field] = true
+	}
+
+	// no fields means every updatable column
+	if len(opts.fields) == 0 {
+		opts.fields = allowed
+	}
+
+	audit := newAudit(model.AUDIT_IMPORT_UPDATE, &model.User{ID: req.UploadedByID, Username: req.UploadedBy}, req.IP)
+	audit.Reason = "Import " + req.Name
+	opts.audit = audit
+
+	return opts, nil
+}
+
+// fieldList returns the updatable columns of the import in their fixed order
+func (o *importOptions) fieldList() string {
+	var fields []string
+	for _, column := range importColumns {
+		if o.fields[column.name] {
+			fields = append(fields, column.name)
+		}
+	}
+	return strings.Join(fields, ",")
+}
+
+// importWorkers is the number of rows validated and inserted in parallel, IMPORT_WORKERS in .env
+func importWorkers() int {
+	if v := viper.GetInt("IMPORT_WORKERS"); v > 0 {
+		return v
+	}
+	return defaultImportWorkers
+}
+
+// importBatchSize is the number of participants inserted per statement, IMPORT_BATCH_SIZE in .env
+func importBatchSize() int {
+	if v := viper.GetInt("IMPORT_BATCH_SIZE"); v > 0 {
+		return v
+	}
+	return defaultImportBatchSize
+}
+
+// importAtomicRows is the most rows of an all_or_nothing import, IMPORT_ATOMIC_ROWS in .env. Such an import is
+// validated as one chunk held in memory before anything is stored
+func importAtomicRows() int {
+	if v := viper.GetInt("IMPORT_ATOMIC_ROWS"); v > 0 {
+		return v
+	}
+	return defaultImportAtomicRows
+}
+
+// checkImportSize rejects an all_or_nothing import with more rows than importAtomicRows
+func checkImportSize(total int, opts *importOptions) error {
+	if opts.transactionMode == model.IMPORT_MODE_ALL_OR_NOTHING && total > importAtomicRows() {
+		return fmt.Errorf("%w: %d baris, maksimal %d baris", ErrImportTooLarge, total, importAtomicRows())
+	}
+	return nil
+}
+
+// BulkCreate registers the import and processes the rows in the background, a file without the required headers
+// is rejected right away. The returned import log is PROCESSING and its progress can be polled with ReadLog
+func (u *usecase) BulkCreate(req request.ImportParticipant, authUser *model.User) (*model.ImportLog, error) {
+	// the upload is removed by the runner once the import is queued
+	queued := false
+	defer func() {
+		if !queued {
+			removeUpload(req.TmpPath)
+		}
+	}()
+
+	opts, err := newImportOptions(req, authUser)
+	if err != nil {
+		return nil, err
+	}
+
+	opts.validator, err = u.participantValidator(req.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	opts.reference, err = helper.Randstring(20)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := u.openImportRows(req, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &model.ImportLog{
+		FileName:        req.Name,
+		Status:          model.IMPORT_PROCESSING,
+		UploadedBy:      req.UploadedBy,
+		Reference:       opts.reference,
+		Type:            req.Type,
+		TransactionMode: opts.transactionMode,
+		Mode:            opts.mode,
+		OverwriteDone:   opts.overwriteDone,
+	}
+
+	if opts.mode == model.IMPORT_MODE_UPSERT {
+		m.Fields = opts.fieldList()
+	}
+
+	importLog, err := u.service.CreateLog(m)
+	if err != nil {
+		rows.Close()
+		return nil, err
+	}
+
+	err = u.queueImport(&importJob{importLogID: importLog.ID, req: req, opts: opts, rows: rows})
+	if err != nil {
+		rows.Close()
+		_ = u.service.UpdateLog(importLog.ID, map[string]interface{}{
+			"status": model.IMPORT_FAILED,
+			"error":  err.Error(),
+		})
+		return nil, err
+	}
+	queued = true
+
+	return importLog, nil
+}
+
+// DryRunImport runs the whole import validation synchronously without storing participants or writing an import log
+func (u *usecase) DryRunImport(req request.ImportParticipant, authUser *model.User) (*model.ImportDryRun, error) {
+	defer removeUpload(req.TmpPath)
+
+	opts, err := newImportOptions(req, authUser)
+	if err != nil {
+		return nil, err
+	}
+
+	opts.validator, err = u.participantValidator(req.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := u.openImportRows(req, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	total, niks, err := scanImport(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkImportSize(total, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	summary, err := u.runImport(0, req, opts, niks, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.ImportDryRun{
+		FileName:      req.Name,
+		TotalRows:     total,
+		ValidRows:     total - summary.failed,
+		InsertedRows:  summary.inserted,
+		UpdatedRows:   summary.updated,
+		UnchangedRows: summary.unchanged,
+		FailedRows:    summary.failed,
+		ErrorCounts:   summary.errorCounts,
+		Path:          summary.path,
+	}, nil
+}
+
+// ReadLog reads the import, only the uploader or an admin may see it
+func (u *usecase) ReadLog(id int, authUser *model.User) (*model.ImportLog, error) {
+	importLog, err := u.service.ReadLogById(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bypassScope(authUser) && importLog.UploadedBy != authUser.Username {
+		return nil, ErrImportNotViewable
+	}
+
+	return importLog, nil
+}
+
+// openImportRows opens the uploaded xlsx, csv or json file with the mapping profile of the request and
+// records the format on opts for the result file. The sheet of the request wins over the sheet saved in the profile
+func (u *usecase) openImportRows(req request.ImportParticipant, opts *importOptions) (*importRows, error) {
+	var columns map[string]string
+	sheet := req.Sheet
+	if req.MappingID > 0 {
+		mapping, err := u.readMapping(req.MappingID, req.OrganizationID)
+		if err != nil {
+			return nil, err
+		}
+
+		columns, err = mappingColumns(mapping)
+		if err != nil {
+			return nil, err
+		}
+
+		if sheet == "" {
+			sheet = mapping.Sheet
+		}
+	}
+
+	var err error
+	opts.format, err = importFormat(req.TmpPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var reader rowReader
+	switch opts.format {
+	case IMPORT_FORMAT_CSV:
+		reader, opts.delimiter, err = newCSVRowReader(req.TmpPath)
+	case IMPORT_FORMAT_JSON:
+		reader, err = newJSONRowReader(req.TmpPath)
+	default:
+		reader, err = newXLSXRowReader(req.TmpPath, sheet)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := newImportRows(reader, columns)
+	if err != nil {
+		reader.Close()
+		return nil, err
+	}
+	return rows, nil
+}
+
+// readImportReference returns the participant reference of the import, imports from before the reference was
+// stored on the log can not be traced back to their participants
+func (u *usecase) readImportReference(id int) (*model.ImportLog, error) {
+	importLog, err := u.service.ReadLogById(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if importLog.Reference == "" {
+		return nil, ErrImportNoReference
+	}
+	return importLog, nil
+}
+
+func (u *usecase) ReadAllImported(id int, req request.ParticipantPaged, authUser *model.User) (*[]model.Participant, error) {
+	importLog, err := u.readImportReference(id)
+	if err != nil {
+		return nil, err
+	}
+
+	criteria := map[string]interface{}{"reference": importLog.Reference}
+	if req.Status != "" {
+		criteria["status"] = req.Status
+	}
+
+	applyScope(criteria, authUser)
+
+	return u.service.ReadAllBy(criteria, req.Search, req.Page, req.Size)
+}
+
+func (u *usecase) CountImported(id int, req request.ParticipantPaged, authUser *model.User) int64 {
+	importLog, err := u.readImportReference(id)
+	if err != nil {
+		return 0
+	}
+
+	criteria := map[string]interface{}{"reference": importLog.Reference}
+	if req.Status != "" {
+		criteria["status"] = req.Status
+	}
+
+	applyScope(criteria, authUser)
+
+	return u.service.Count(criteria, req.Search)
+}
+
+// RollbackImport soft-deletes the participants inserted by the import as long as none of them has been processed,
+// participants updated by an upsert import keep their new values. Only the uploader or an admin may roll it back
+func (u *usecase) RollbackImport(id int, authUser *model.User, ip string) (*model.ImportLog, error) {
+	importLog, err := u.readImportReference(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bypassScope(authUser) && importLog.UploadedBy != authUser.Username {
+		return nil, ErrImportNotUploader
+	}
+
+	switch importLog.Status {
+	case model.IMPORT_PROCESSING:
+		return nil, ErrImportNotFinished
+	case model.IMPORT_ROLLED_BACK:
+		return nil, ErrImportRolledBack
+	}
+
+	audit := newAudit(model.AUDIT_IMPORT_ROLLBACK, authUser, ip)
+	audit.Reason = "Rollback import " + importLog.FileName
+
+	processed, err := u.service.RollbackImport(importLog.ID, importLog.Reference, audit)
+	if err != nil {
+		return nil, err
+	}
+
+	if processed > 0 {
+		return nil, fmt.Errorf("%w: %d peserta", ErrImportProcessed, processed)
+	}
+
+	return u.service.ReadLogById(importLog.ID)
+}
+
+func importErrorCriteria(id int, req request.ImportErrorPaged) map[string]interface{} {
+	criteria := map[string]interface{}{"import_log_id": id}
+	if req.Code != "" {
+		criteria["code"] = req.Code
+	}
+	if req.Column != "" {
+		criteria["column"] = req.Column
+	}
+	return criteria
+}
+
+func (u *usecase) ReadAllImportErrors(id int, req request.ImportErrorPaged, authUser *model.User) ([]*model.ImportError, error) {
+	_, err := u.ReadLog(id, authUser)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.service.ReadAllImportErrorBy(importErrorCriteria(id, req), req.Page, req.Size)
+}
+
+func (u *usecase) CountImportErrors(id int, req request.ImportErrorPaged) int64 {
+	return u.service.CountImportErrors(importErrorCriteria(id, req))
+}
+
+// SummarizeImportErrors counts the errors of the import per error code, the most frequent first
+func (u *usecase) SummarizeImportErrors(id int, authUser *model.User) ([]*model.ImportErrorCount, error) {
+	_, err := u.ReadLog(id, authUser)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.service.CountImportErrorsByCode(id)
+}
+
+// importSummary counts the results of the rows of an import
+type importSummary struct {
+	inserted    int
+	updated     int
+	unchanged   int
+	failed      int
+	errorCounts map[string]int
+	path        string
+	err         error
+}
+
+func (u *usecase) processImport(importLogID int, req request.ImportParticipant, opts *importOptions, rows *importRows) {
+	defer removeUpload(req.TmpPath)
+	defer func() {
+		if r := recover(); r != nil {
+			helper.CommonLogger().Error(r)
+			_ = u.service.UpdateLog(importLogID, map[string]interface{}{
+				"status": model.IMPORT_FAILED,
+				"error":  fmt.Sprintf("%v", r),
+			})
+		}
+	}()
+
+	total, niks, err := scanImport(rows)
+	if err == nil {
+		err = checkImportSize(total, opts)
+	}
+	if err != nil {
+		_ = u.service.UpdateLog(importLogID, map[string]interface{}{
+			"status": model.IMPORT_FAILED,
+			"error":  err.Error(),
+		})
+		return
+	}
+
+	_ = u.service.UpdateLog(importLogID, map[string]interface{}{"total_rows": total})
+
+	summary, err := u.runImport(importLogID, req, opts, niks, false)
+	if err != nil {
+		_ = u.service.UpdateLog(importLogID, map[string]interface{}{
+			"status": model.IMPORT_FAILED,
+			"error":  err.Error(),
+		})
+		return
+	}
+
+	fields := map[string]interface{}{
+		"processed_rows": total,
+		"inserted_rows":  summary.inserted,
+		"updated_rows":   summary.updated,
+		"unchanged_rows": summary.unchanged,
+		"success_rows":   summary.inserted + summary.updated + summary.unchanged,
+		"failed_rows":    summary.failed,
+		"path":           summary.path,
+	}
+
+	if summary.err != nil {
+		fields["status"] = model.IMPORT_FAILED
+		fields["error"] = summary.err.Error()
+	} else if summary.failed > 0 && summary.failed == total {
+		fields["status"] = model.IMPORT_ERROR_ALL
+	} else if summary.failed > 0 {
+		fields["status"] = model.IMPORT_SUCCESS_WITH_ERROR
+	} else {
+		fields["status"] = model.IMPORT_SUCCESS_ALL
+	}
+
+	_ = u.service.UpdateLog(importLogID, fields)
+}
+
+// scanImport is the first pass over the file and closes it, it counts the rows and collects the rows of every NIK
+// so duplicates across the whole file are known while the rows are processed chunk by chunk
+func scanImport(rows *importRows) (int, map[string][]int, error) {
+	defer rows.Close()
+
+	total := 0
+	niks := make(map[string][]int)
+	for {
+		row, err := rows.Next()
+		if err == io.EOF {
+			return total, niks, nil
+		}
+		if err != nil {
+			return 0, nil, err
+		}
+
+		total++
+		if row.NIK != "" {
+			niks[row.NIK] = append(niks[row.NIK], row.Row)
+		}
+	}
+}
+
+// runImport is the second pass over the file, the rows are validated and stored chunk by chunk so only one chunk
+// is kept in memory. An all_or_nothing import stores nothing when a row fails, so its chunk is the whole file
+// and its size is bounded by checkImportSize.
+// A dry run only validates
+func (u *usecase) runImport(importLogID int, req request.ImportParticipant, opts *importOptions, niks map[string][]int, dryRun bool) (*importSummary, error) {
+	rows, err := u.openImportRows(req, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := newImportResult(opts)
+	summary := &importSummary{errorCounts: make(map[string]int)}
+
+	chunkSize := importBatchSize()
+	if opts.transactionMode == model.IMPORT_MODE_ALL_OR_NOTHING {
+		chunkSize = 0
+	}
+
+	processed := 0
+	for {
+		chunk, err := readImportChunk(rows, chunkSize)
+		if err != nil {
+			result.Close()
+			return nil, err
+		}
+		if len(chunk) == 0 {
+			break
+		}
+
+		ops, errs := u.validateImportRows(importLogID, processed, chunk, niks, opts)
+		processed += len(chunk)
+
+		var invalid []*request.ParticipantInput
+		for _, row := range chunk {
+			if row.Result == model.IMPORT_ROW_FAILED {
+				invalid = append(invalid, row)
+			}
+		}
+
+		switch {
+		case dryRun:
+		case opts.transactionMode == model.IMPORT_MODE_ALL_OR_NOTHING && len(invalid) > 0:
+			for i, row := range chunk {
+				if row.Result != model.IMPORT_ROW_FAILED {
+					failImportRow(row, &errs[i], importIssue("", model.IMPORT_ERROR_SKIPPED, "Tidak disimpan karena ada baris lain yang gagal"))
+				}
+			}
+		default:
+			// a chunk that could not be stored is reported as failed and the import goes on with the next chunk,
+			// an all_or_nothing import is a single chunk so nothing of it was stored
+			err = u.applyImportRows(chunk, ops, errs, opts)
+			if err != nil {
+				helper.CommonLogger().Error(err)
+			}
+			if err != nil && opts.transactionMode == model.IMPORT_MODE_ALL_OR_NOTHING {
+				summary.err = err
+			}
+		}
+
+		var rowErrors []*model.ImportError
+		for i, row := range chunk {
+			for _, importError := range errs[i] {
+				importError.ImportLogID = importLogID
+				importError.Row = row.Row
+				summary.errorCounts[importError.Code]++
+				rowErrors = append(rowErrors, importError)
+			}
+		}
+
+		if !dryRun && len(rowErrors) > 0 {
+			err = u.service.CreateImportErrors(rowErrors, importBatchSize())
+			if err != nil {
+				helper.CommonLogger().Error(err)
+			}
+		}
+
+		for _, row := range chunk {
+			switch row.Result {
+			case model.IMPORT_ROW_INSERTED:
+				summary.inserted++
+			case model.IMPORT_ROW_UPDATED:
+				summary.updated++
+			case model.IMPORT_ROW_UNCHANGED:
+				summary.unchanged++
+			default:
+				summary.failed++
+			}
+		}
+
+		// an insert import reports its failed rows only, an upsert import reports the result of every row
+		var reported []*request.ParticipantInput
+		for _, row := range chunk {
+			if opts.mode == model.IMPORT_MODE_UPSERT || row.Result == model.IMPORT_ROW_FAILED {
+				reported = append(reported, row)
+			}
+		}
+
+		for _, row := range reported {
+			err = result.Write(row)
+			if err != nil {
+				helper.CommonLogger().Error(err)
+				break
+			}
+		}
+	}
+
+	summary.path, err = result.Close()
+	if err != nil {
+		helper.CommonLogger().Error(err)
+	}
+
+	return summary, nil
+}
+
+// readImportChunk reads up to size rows, a size of 0 reads every remaining row
+func readImportChunk(rows *importRows, size int) ([]*request.ParticipantInput, error) {
+	var chunk []*request.ParticipantInput
+	for size == 0 || len(chunk) < size {
+		row, err := rows.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		chunk = append(chunk, row)
+	}
+	return chunk, nil
+}
+
+// validateImportRows fills the note and the result of every row and returns what every valid row does to the database
+// with the errors of every row, the rows are checked by a pool of workers. The progress is written to the import log counting from processed,
+// a dry run has no import log and passes 0
+func (u *usecase) validateImportRows(importLogID, processed int, rows []*request.ParticipantInput, niks map[string][]int, opts *importOptions) ([]*importRowOp, [][]*model.ImportError) {
+	ops := make([]*importRowOp, len(rows))
+	errs := make([][]*model.ImportError, len(rows))
+
+	jobs := make(chan int)
+	done := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < importWorkers(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				row := rows[i]
+				ops[i], errs[i] = u.checkImportRow(row, niks, opts)
+				if len(errs[i]) > 0 {
+					row.Note = importErrorNote(errs[i])
+					row.Result = model.IMPORT_ROW_FAILED
+				}
+				done <- i
+			}
+		}()
+	}
+
+	go func() {
+		for i := range rows {
+			jobs <- i
+		}
+		close(jobs)
+		wg.Wait()
+		close(done)
+	}()
+
+	for range done {
+		processed++
+		if importLogID > 0 && processed%importProgressStep == 0 {
+			_ = u.service.UpdateLog(importLogID, map[string]interface{}{"processed_rows": processed})
+		}
+	}
+
+	return ops, errs
+}
+
+// checkImportRow validates and plans one row, a panic fails the row instead of stopping the worker
+// so every row of the chunk is still reported as done
+func (u *usecase) checkImportRow(row *request.ParticipantInput, niks map[string][]int, opts *importOptions) (op *importRowOp, issues []*model.ImportError) {
+	defer func() {
+		if r := recover(); r != nil {
+			helper.CommonLogger().Error(r)
+			op, issues = nil, []*model.ImportError{importIssue("", model.IMPORT_ERROR_INTERNAL, "Terjadi kesalahan saat memeriksa baris")}
+		}
+	}()
+
+	issues = u.validateImportRow(row, opts)
+	if duplicate := duplicateNIKError(row, niks); duplicate != nil {
+		issues = append(issues, duplicate)
+	}
+
+	if len(issues) == 0 {
+		var planError *model.ImportError
+		op, planError = u.planImportRow(row, opts)
+		if planError != nil {
+			issues = append(issues, planError)
+		}
+	}
+	return op, issues
+}
+
+// planImportInsert inserts the row as a new participant, the uploader may only add participants living in
+// their own region
+func planImportInsert(row *request.ParticipantInput, participant *model.Participant, opts *importOptions) (*importRowOp, *model.ImportError) {
+	if !inScope(participant.ResidenceProvinsi, participant.ResidenceKota, opts.authUser) {
+		return nil, importIssue("residence_provinsi", model.IMPORT_ERROR_OUT_OF_SCOPE, "Domisili peserta di luar wilayah anda")
+	}
+
+	row.Result = model.IMPORT_ROW_INSERTED
+	return &importRowOp{insert: participant}, nil
+}
+
+// planImportRow decides whether a valid row is inserted, updated or left unchanged and sets its result,
+// a returned error means the row can not be imported
+func (u *usecase) planImportRow(row *request.ParticipantInput, opts *importOptions) (*importRowOp, *model.ImportError) {
+	participant := newImportParticipant(row, opts.reference, opts.participantType)
+	participant.BirthDate, participant.NIKFlags, _ = u.readNIK(participant.NIK, participant.Provinsi, participant.Kota)
+	if opts.mode == model.IMPORT_MODE_INSERT {
+		return planImportInsert(row, participant, opts)
+	}
+
+	existing, err := u.service.ReadByNIK(participant.NIK)
+	if err != nil {
+		return nil, importIssue("nik", model.IMPORT_ERROR_LOOKUP_FAILED, "Gagal memeriksa NIK")
+	}
+
+	if existing == nil {
+		return planImportInsert(row, participant, opts)
+	}
+
+	if !inScope(existing.ResidenceProvinsi, existing.ResidenceKota, opts.authUser) {
+		return nil, importIssue("nik", model.IMPORT_ERROR_OUT_OF_SCOPE, "Peserta dengan NIK ini di luar wilayah anda")
+	}
+
+	if model.ParticipantStatus(existing.Status) == model.STATUS_DONE && !opts.overwriteDone {
+		return nil, importIssue("status", model.IMPORT_ERROR_PARTICIPANT_DONE, "Peserta sudah DONE, tidak diperbarui")
+	}
+
+	fields := make(map[string]interface{})
+	for _, column := range importColumns {
+		if !opts.fields[column.name] {
+			continue
+		}
+		if value := column.value(participant); value != column.value(existing) {
+			fields[column.name] = value
+		}
+	}
+
+	// the attributes derived from the NIK follow the KTP address the participant ends up with
+	provinsi, kota := existing.Provinsi, existing.Kota
+	if opts.fields["provinsi"] {
+		provinsi = participant.Provinsi
+	}
+	if opts.fields["kota"] {
+		kota = participant.Kota
+	}
+
+	birthDate, flags, err := u.readNIK(existing.NIK, provinsi, kota)
+	if birthDate != nil && (existing.BirthDate == nil || !existing.BirthDate.Equal(*birthDate)) {
+		fields["birth_date"] = *birthDate
+	}
+	if err == nil && flags != existing.NIKFlags {
+		fields["nik_flags"] = flags
+	}
+
+	if len(fields) == 0 {
+		row.Result = model.IMPORT_ROW_UNCHANGED
+		return nil, nil
+	}
+
+	row.Result = model.IMPORT_ROW_UPDATED
+	return &importRowOp{update: &model.ParticipantUpdate{ID: existing.ID, Fields: fields}}, nil
+}
+
+// duplicateNIKError flags a row whose NIK appears more than once in the file with the rows it collides with
+func duplicateNIKError(row *request.ParticipantInput, niks map[string][]int) *model.ImportError {
+	same := niks[row.NIK]
+	if row.NIK == "" || len(same) < 2 {
+		return nil
+	}
+
+	var others []string
+	for _, line := range same {
+		if line != row.Row {
+			others = append(others, strconv.Itoa(line))
+		}
+	}
+	return importIssue("nik", model.IMPORT_ERROR_NIK_DUPLICATE, fmt.Sprintf("NIK duplikat dengan baris %s", strings.Join(others, ", ")))
+}
+
+// applyImportRows stores the planned rows, in partial mode every batch is committed on its own so a failing batch
+// only loses its own rows and the next batches are still stored. The rows of a batch that was not stored are
+// marked as failed, the first error is returned
+func (u *usecase) applyImportRows(rows []*request.ParticipantInput, ops []*importRowOp, errs [][]*model.ImportError, opts *importOptions) error {
+	batchSize := importBatchSize()
+	chunkSize := batchSize
+	if opts.transactionMode == model.IMPORT_MODE_ALL_OR_NOTHING {
+		chunkSize = len(rows)
+	}
+
+	var firstErr error
+	for start := 0; start < len(rows); start += chunkSize {
+		end := start + chunkSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+
+		var inserts []*model.Participant
+		var updates []*model.ParticipantUpdate
+		for _, op := range ops[start:end] {
+			if op == nil {
+				continue
+			}
+			if op.insert != nil {
+				inserts = append(inserts, op.insert)
+			}
+			if op.update != nil {
+				updates = append(updates, op.update)
+			}
+		}
+
+		if len(inserts) == 0 && len(updates) == 0 {
+			continue
+		}
+
+		err := u.service.ImportBatch(inserts, updates, batchSize, opts.audit)
+		if err != nil {
+			markImportUnsaved(rows, ops, errs, start, end)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// markImportUnsaved marks the planned rows from start up to end as failed because they were not stored
+func markImportUnsaved(rows []*request.ParticipantInput, ops []*importRowOp, errs [][]*model.ImportError, start, end int) {
+	for i := start; i < end; i++ {
+		if ops[i] != nil {
+			failImportRow(rows[i], &errs[i], importIssue("", model.IMPORT_ERROR_NOT_SAVED, "Gagal disimpan"))
+		}
+	}
+}
+
+// failImportRow adds an error to a row that passed the validation and marks the row as failed
+func failImportRow(row *request.ParticipantInput, errs *[]*model.ImportError, issue *model.ImportError) {
+	*errs = append(*errs, issue)
+	row.Result = model.IMPORT_ROW_FAILED
+	row.Note = importErrorNote(*errs)
+}
+
+func importIssue(column, code, message string) *model.ImportError {
+	return &model.ImportError{Column: column, Code: code, Message: message}
+}
+
+// importErrorNote is the Catatan of the result file, the messages of the row in the format the notes always had
+func importErrorNote(importErrors []*model.ImportError) string {
+	var note []string
+	for _, importError := range importErrors {
+		note = append(note, importError.Message+" \n")
+	}
+	return strings.Join(note, ",")
+}
+
+// validateImportRow checks the row against the rules of the program, the regions and the status.
+// An insert import also rejects a NIK that is already registered
+func (u *usecase) validateImportRow(row *request.ParticipantInput, opts *importOptions) []*model.ImportError {
+	var issues []*model.ImportError
+	failed := make(map[string]bool)
+	for _, issue := range opts.validator.Validate(inputValues(row)) {
+		issues = append(issues, importIssue(issue.Field, issue.Code, issue.Message))
+		failed[issue.Field] = true
+	}
+
+	if opts.mode == model.IMPORT_MODE_INSERT && !failed["nik"] {
+		countBynik := u.service.CheckId(map[string]interface{}{"nik": row.NIK})
+		if countBynik > 0 {
+			issues = append(issues, importIssue("nik", model.IMPORT_ERROR_NIK_REGISTERED, "NIK Sudah Terdaftar"))
+		}
+	}
+
+	if issue := u.matchRegion(&row.Provinsi, &row.Kota, &row.Kecamatan, &row.Kelurahan, ""); issue != nil {
+		issues = append(issues, importIssue(issue.column, issue.code, issue.note))
+	}
+
+	if issue := u.matchRegion(&row.ResidenceProvinsi, &row.ResidenceKota, &row.ResidenceKecamatan, &row.ResidenceKelurahan, " Domisili"); issue != nil {
+		issues = append(issues, importIssue("residence_"+issue.column, issue.code, issue.note))
+	}
+
+	if opts.validator.Has(validator.RULE_NIK) && !failed["nik"] {
+		_, _, err := u.readNIK(row.NIK, row.Provinsi, row.Kota)
+		if errors.Is(err, ErrNIKRegion) {
+			issues = append(issues, importIssue("nik", model.IMPORT_ERROR_NIK_REGION_NOT_FOUND, err.Error()))
+		} else if err != nil {
+			issues = append(issues, importIssue("nik", model.IMPORT_ERROR_LOOKUP_FAILED, "Gagal memeriksa kode wilayah NIK"))
+		}
+	}
+
+	if row.Status == "" {
+		issues = append(issues, importIssue("status", model.IMPORT_ERROR_REQUIRED, "Status Kosong"))
+	} else if !model.ParticipantStatus(row.Status).IsValid() {
+		issues = append(issues, importIssue("status", model.IMPORT_ERROR_UNKNOWN_STATUS, "Status Tidak Dikenal"))
+	}
+
+	return issues
+}
+
+func newImportParticipant(row *request.ParticipantInput, reference, participantType string) *model.Participant {
+	return &model.Participant{
+		Name:               strings.TrimSpace(row.Name),
+		NIK:                strings.TrimSpace(row.NIK),
+		Gender:             row.Gender,
+		Phone:              row.Phone,
+		Address:            row.Address,
+		RT:                 row.RT,
+		RW:                 row.RW,
+		Provinsi:           strings.ToUpper(row.Provinsi),
+		Kota:               strings.ToUpper(row.Kota),
+		Kecamatan:          strings.ToUpper(row.Kecamatan),
+		Kelurahan:          strings.ToUpper(row.Kelurahan),
+		KodePOS:            row.KodePOS,
+		ResidenceAddress:   row.ResidenceAddress,
+		ResidenceRT:        row.ResidenceRT,
+		ResidenceRW:        row.ResidenceRW,
+		ResidenceProvinsi:  strings.ToUpper(row.ResidenceProvinsi),
+		ResidenceKota:      strings.ToUpper(row.ResidenceKota),
+		ResidenceKecamatan: strings.ToUpper(row.ResidenceKecamatan),
+		ResidenceKelurahan: strings.ToUpper(row.ResidenceKelurahan),
+		ResidenceKodePOS:   row.ResidenceKodePOS,
+		Status:             row.Status,
+		Reference:          reference,
+		Type:               participantType,
+	}
+}
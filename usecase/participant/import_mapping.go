@@ -0,0 +1,139 @@
+/*
+ * Created on 19/10/26 09.20
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package participant
+
+import (
+	"bumn-sembako-be/model"
+	"bumn-sembako-be/request"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// MappingOrganization is the organization whose mapping profiles the user may use, 0 for every organization
+func MappingOrganization(authUser *model.User) int {
+	if bypassScope(authUser) {
+		return 0
+	}
+	return int(authUser.OrganizationID)
+}
+
+// readMapping hides the mapping profiles of other organizations as if they do not exist,
+// an organizationID of 0 accepts the profile of any organization
+func (u *usecase) readMapping(id, organizationID int) (*model.ImportMapping, error) {
+	mapping, err := u.service.ReadMappingById(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if organizationID != 0 && mapping.OrganizationID != organizationID {
+		return nil, fmt.Errorf("id is not exists")
+	}
+	return mapping, nil
+}
+
+func mappingColumns(mapping *model.ImportMapping) (map[string]string, error) {
+	columns := make(map[string]string)
+	if len(mapping.Columns) == 0 {
+		return columns, nil
+	}
+
+	err := json.Unmarshal(mapping.Columns, &columns)
+	if err != nil {
+		return nil, fmt.Errorf("mapping kolom tidak valid: %v", err)
+	}
+	return columns, nil
+}
+
+func (u *usecase) ReadAllMapping(req request.ImportMappingPaged, authUser *model.User) ([]*model.ImportMapping, error) {
+	criteria := make(map[string]interface{})
+	if req.OrganizationID > 0 {
+		criteria["organization_id"] = req.OrganizationID
+	}
+
+	if organizationID := MappingOrganization(authUser); organizationID != 0 {
+		criteria["organization_id"] = organizationID
+	}
+
+	return u.service.ReadAllMappingBy(criteria)
+}
+
+func (u *usecase) CreateMapping(input request.ImportMappingInput, authUser *model.User) (*model.ImportMapping, error) {
+	mapping := &model.ImportMapping{}
+	err := fillMapping(mapping, input, authUser)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.service.SaveMapping(mapping)
+}
+
+func (u *usecase) UpdateMapping(id int, input request.ImportMappingInput, authUser *model.User) (*model.ImportMapping, error) {
+	mapping, err := u.readMapping(id, MappingOrganization(authUser))
+	if err != nil {
+		return nil, err
+	}
+
+	err = fillMapping(mapping, input, authUser)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.service.SaveMapping(mapping)
+}
+
+func (u *usecase) DeleteMapping(id int, authUser *model.User) error {
+	_, err := u.readMapping(id, MappingOrganization(authUser))
+	if err != nil {
+		return err
+	}
+
+	return u.service.DeleteMapping(id)
+}
+
+// fillMapping validates the input against the import fields, a user of an organization always saves
+// the profile for their own organization
+func fillMapping(mapping *model.ImportMapping, input request.ImportMappingInput, authUser *model.User) error {
+	if strings.TrimSpace(input.Name) == "" {
+		return fmt.Errorf("nama mapping wajib diisi")
+	}
+
+	organizationID := input.OrganizationID
+	if scoped := MappingOrganization(authUser); scoped != 0 {
+		organizationID = scoped
+	}
+	if organizationID == 0 {
+		return fmt.Errorf("organisasi wajib diisi")
+	}
+
+	known := make(map[string]bool)
+	for _, field := range importFields {
+		known[field.key] = true
+	}
+
+	columns := make(map[string]string)
+	for key, header := range input.Columns {
+		if !known[key] {
+			return fmt.Errorf("field import tidak dikenal: %s", key)
+		}
+		if strings.TrimSpace(header) == "" {
+			return fmt.Errorf("header untuk field %s kosong", key)
+		}
+		columns[key] = strings.TrimSpace(header)
+	}
+
+	raw, err := json.Marshal(columns)
+	if err != nil {
+		return err
+	}
+
+	mapping.OrganizationID = organizationID
+	mapping.Name = strings.TrimSpace(input.Name)
+	mapping.Sheet = strings.TrimSpace(input.Sheet)
+	mapping.Columns = raw
+	return nil
+}
@@ -0,0 +1,87 @@
+/*
+ * Created on 21/10/26 09.10
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package participant
+
+import (
+	"bumn-sembako-be/helper"
+	"bumn-sembako-be/request"
+	"errors"
+	"os"
+
+	"github.com/spf13/viper"
+)
+
+var ErrImportQueueFull = errors.New("antrian import penuh, silahkan coba lagi nanti")
+
+const defaultImportQueueSize = 20
+const defaultImportRunners = 2
+
+// importJob is an import waiting in the queue for a runner
+type importJob struct {
+	importLogID int
+	req         request.ImportParticipant
+	opts        *importOptions
+	rows        *importRows
+}
+
+// importQueueSize is how many imports may wait for a runner, IMPORT_QUEUE_SIZE in .env
+func importQueueSize() int {
+	if v := viper.GetInt("IMPORT_QUEUE_SIZE"); v > 0 {
+		return v
+	}
+	return defaultImportQueueSize
+}
+
+// importRunners is how many imports are processed at the same time, IMPORT_RUNNERS in .env
+func importRunners() int {
+	if v := viper.GetInt("IMPORT_RUNNERS"); v > 0 {
+		return v
+	}
+	return defaultImportRunners
+}
+
+// startImportQueue fails the imports a previous run of the server left PROCESSING, nothing processes them anymore,
+// and starts the runners of the queue
+func (u *usecase) startImportQueue() {
+	failed, err := u.service.FailProcessingLogs("import terhenti karena server dimulai ulang")
+	if err != nil {
+		helper.CommonLogger().Error(err)
+	} else if failed > 0 {
+		helper.CommonLogger().WithField("imports", failed).Warn("stale imports marked as failed")
+	}
+
+	u.imports = make(chan *importJob, importQueueSize())
+	for i := 0; i < importRunners(); i++ {
+		go func() {
+			for job := range u.imports {
+				u.processImport(job.importLogID, job.req, job.opts, job.rows)
+			}
+		}()
+	}
+}
+
+// queueImport hands the import to a runner without waiting for a free place in the queue
+func (u *usecase) queueImport(job *importJob) error {
+	select {
+	case u.imports <- job:
+		return nil
+	default:
+		return ErrImportQueueFull
+	}
+}
+
+// removeUpload deletes the uploaded file once the import is done with it
+func removeUpload(path string) {
+	if path == "" {
+		return
+	}
+
+	err := os.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		helper.CommonLogger().Error(err)
+	}
+}
@@ -0,0 +1,471 @@
+/*
+ * Created on 19/10/26 08.55
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package participant
+
+import (
+	"bufio"
+	"bumn-sembako-be/helper"
+	"bumn-sembako-be/request"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+const IMPORT_FORMAT_XLSX = "xlsx"
+const IMPORT_FORMAT_CSV = "csv"
+const IMPORT_FORMAT_JSON = "json"
+
+// csvSniffSize is how much of a CSV file is looked at to detect its encoding and delimiter
+const csvSniffSize = 64 * 1024
+
+// csvDelimiters are the separators recognised in the header line of a CSV file, the first one wins a tie
+var csvDelimiters = []rune{',', ';', '\t', '|'}
+
+// rowReader returns the rows of an import file one by one without loading the whole file, the first row is the header
+type rowReader interface {
+	// Next returns the cells of the next row, io.EOF after the last row
+	Next() ([]string, error)
+	// Line is the position of the row last returned by Next as the user sees it in the file
+	Line() int
+	Close() error
+}
+
+// importField is a column of the import file, header is the default header used by the import template
+type importField struct {
+	key    string
+	header string
+	set    func(row *request.ParticipantInput, value string)
+}
+
+var importFields = []importField{
+	{"name", "Nama", func(r *request.ParticipantInput, v string) { r.Name = strings.TrimSpace(v) }},
+	{"nik", "NIK", func(r *request.ParticipantInput, v string) { r.NIK = strings.TrimSpace(v) }},
+	{"gender", "Jenis Kelamin", func(r *request.ParticipantInput, v string) { r.Gender = v }},
+	{"phone", "No Handphone", func(r *request.ParticipantInput, v string) { r.Phone = v }},
+	{"address", "Alamat Sesuai KTP", func(r *request.ParticipantInput, v string) { r.Address = v }},
+	{"rt", "RT", func(r *request.ParticipantInput, v string) { r.RT = v }},
+	{"rw", "RW", func(r *request.ParticipantInput, v string) { r.RW = v }},
+	{"provinsi", "Provinsi", func(r *request.ParticipantInput, v string) { r.Provinsi = v }},
+	{"kota", "Kota/Kabupaten", func(r *request.ParticipantInput, v string) { r.Kota = v }},
+	{"kecamatan", "Kecamatan", func(r *request.ParticipantInput, v string) { r.Kecamatan = v }},
+	{"kelurahan", "Kelurahan", func(r *request.ParticipantInput, v string) { r.Kelurahan = v }},
+	{"kode_pos", "Kode Pos", func(r *request.ParticipantInput, v string) { r.KodePOS = v }},
+	{"residence_address", "Alamat Domisili", func(r *request.ParticipantInput, v string) { r.ResidenceAddress = v }},
+	{"residence_rt", "RT Domisili", func(r *request.ParticipantInput, v string) { r.ResidenceRT = v }},
+	{"residence_rw", "RW Domisili", func(r *request.ParticipantInput, v string) { r.ResidenceRW = v }},
+	{"residence_provinsi", "Provinsi Domisili", func(r *request.ParticipantInput, v string) { r.ResidenceProvinsi = v }},
+	{"residence_kota", "Kota/Kabupaten Domisili", func(r *request.ParticipantInput, v string) { r.ResidenceKota = v }},
+	{"residence_kecamatan", "Kecamatan Domisili", func(r *request.ParticipantInput, v string) { r.ResidenceKecamatan = v }},
+	{"residence_kelurahan", "Kelurahan Domisili", func(r *request.ParticipantInput, v string) { r.ResidenceKelurahan = v }},
+	{"residence_kode_pos", "Kode Pos Domisili", func(r *request.ParticipantInput, v string) { r.ResidenceKodePOS = v }},
+	{"status", "Status", func(r *request.ParticipantInput, v string) { r.Status = v }},
+}
+
+// headerKey is the header cell as it is matched, ignoring case, spacing and a byte order mark left in the first cell
+func headerKey(header string) string {
+	header = strings.ReplaceAll(header, "\ufeff", "")
+	return strings.ToLower(strings.Join(strings.Fields(header), " "))
+}
+
+// mapImportHeader finds the column of every import field in the header row, columns overrides the default header
+// of a field and the field key itself is accepted as well. Every field is required, the missing ones are reported together
+func mapImportHeader(header []string, columns map[string]string) (map[string]int, error) {
+	positions := make(map[string]int)
+	for i, cell := range header {
+		key := headerKey(cell)
+		if _, ok := positions[key]; !ok && key != "" {
+			positions[key] = i
+		}
+	}
+
+	index := make(map[string]int)
+	var missing []string
+	for _, field := range importFields {
+		name := field.header
+		if custom, ok := columns[field.key]; ok && strings.TrimSpace(custom) != "" {
+			name = custom
+		}
+
+		i, ok := positions[headerKey(name)]
+		if !ok {
+			i, ok = positions[field.key]
+		}
+		if !ok {
+			missing = append(missing, name)
+			continue
+		}
+		index[field.key] = i
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("kolom wajib tidak ditemukan: %s", strings.Join(missing, ", "))
+	}
+	return index, nil
+}
+
+// importRows maps the rows of a reader to participant inputs
+type importRows struct {
+	reader rowReader
+	index  map[string]int
+}
+
+// newImportRows reads the header of the reader, a file without the required headers is rejected before any row is read
+func newImportRows(reader rowReader, columns map[string]string) (*importRows, error) {
+	header, err := reader.Next()
+	if err == io.EOF {
+		return nil, fmt.Errorf("file import kosong")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca file: %v", err)
+	}
+
+	index, err := mapImportHeader(header, columns)
+	if err != nil {
+		return nil, err
+	}
+
+	return &importRows{reader: reader, index: index}, nil
+}
+
+// Next returns the next row that is not blank, io.EOF after the last row
+func (r *importRows) Next() (*request.ParticipantInput, error) {
+	for {
+		cells, err := r.reader.Next()
+		if err == io.EOF {
+			return nil, err
+		}
+		if err != nil {
+			return nil, fmt.Errorf("gagal membaca file: %v", err)
+		}
+
+		if blankRow(cells) {
+			continue
+		}
+
+		row := &request.ParticipantInput{Row: r.reader.Line()}
+		for _, field := range importFields {
+			if i := r.index[field.key]; i < len(cells) {
+				field.set(row, cells[i])
+			}
+		}
+		return row, nil
+	}
+}
+
+func (r *importRows) Close() error {
+	return r.reader.Close()
+}
+
+func blankRow(cells []string) bool {
+	for _, cell := range cells {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+type xlsxRowReader struct {
+	*helper.XLSXReader
+}
+
+func newXLSXRowReader(path, sheet string) (rowReader, error) {
+	reader, err := helper.OpenXLSX(path, sheet)
+	if err != nil {
+		return nil, fmt.Errorf("error when open file: %v", err)
+	}
+	return &xlsxRowReader{reader}, nil
+}
+
+// importFormat returns the format of the uploaded file by its extension
+func importFormat(path string) (string, error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".xlsx":
+		return IMPORT_FORMAT_XLSX, nil
+	case ".csv":
+		return IMPORT_FORMAT_CSV, nil
+	case ".json":
+		return IMPORT_FORMAT_JSON, nil
+	default:
+		return "", fmt.Errorf("format file tidak didukung: %s", ext)
+	}
+}
+
+type csvRowReader struct {
+	file   *os.File
+	reader *csv.Reader
+	line   int
+}
+
+// newCSVRowReader decodes the file to UTF-8 while reading and detects the delimiter from the header line,
+// the detected delimiter is returned so the result file can use it as well
+func newCSVRowReader(path string) (rowReader, rune, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error when open file: %v", err)
+	}
+
+	buffered := bufio.NewReaderSize(file, csvSniffSize)
+	sample, _ := buffered.Peek(csvSniffSize)
+
+	var content io.Reader = buffered
+	switch {
+	case bytes.HasPrefix(sample, []byte{0xEF, 0xBB, 0xBF}):
+		_, _ = buffered.Discard(3)
+		sample = sample[3:]
+	case bytes.HasPrefix(sample, []byte{0xFF, 0xFE}), bytes.HasPrefix(sample, []byte{0xFE, 0xFF}):
+		// a decoder keeps whether it has seen the BOM, the sample gets its own so the stream still skips the BOM
+		encoding := unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM)
+		content = transform.NewReader(buffered, encoding.NewDecoder())
+		sample, _ = encoding.NewDecoder().Bytes(sample[:len(sample)&^1])
+	case !looksUTF8(sample):
+		// older Excel versions save CSV as Windows-1252
+		content = transform.NewReader(buffered, charmap.Windows1252.NewDecoder())
+		sample, _ = charmap.Windows1252.NewDecoder().Bytes(sample)
+	}
+
+	delimiter := detectDelimiter(sample)
+
+	reader := csv.NewReader(content)
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	return &csvRowReader{file: file, reader: reader}, delimiter, nil
+}
+
+// looksUTF8 accepts a sample that is cut in the middle of its last character
+func looksUTF8(sample []byte) bool {
+	for len(sample) > 0 {
+		r, size := utf8.DecodeRune(sample)
+		if r == utf8.RuneError && size == 1 {
+			return len(sample) < utf8.UTFMax && !utf8.FullRune(sample)
+		}
+		sample = sample[size:]
+	}
+	return true
+}
+
+func (r *csvRowReader) Next() ([]string, error) {
+	cells, err := r.reader.Read()
+	if err == nil {
+		r.line, _ = r.reader.FieldPos(0)
+	}
+	return cells, err
+}
+
+func (r *csvRowReader) Line() int {
+	return r.line
+}
+
+func (r *csvRowReader) Close() error {
+	return r.file.Close()
+}
+
+func detectDelimiter(sample []byte) rune {
+	line := string(sample)
+	if end := strings.IndexByte(line, '\n'); end >= 0 {
+		line = line[:end]
+	}
+
+	counts := make(map[rune]int)
+	quoted := false
+	for _, r := range line {
+		if r == '"' {
+			quoted = !quoted
+			continue
+		}
+		if !quoted {
+			counts[r]++
+		}
+	}
+
+	delimiter := csvDelimiters[0]
+	for _, candidate := range csvDelimiters {
+		if counts[candidate] > counts[delimiter] {
+			delimiter = candidate
+		}
+	}
+	return delimiter
+}
+
+// jsonRowReader streams an array of flat objects, the header is every key in the order it first appears
+// and is collected by a first pass over the keys only
+type jsonRowReader struct {
+	file      *os.File
+	decoder   *json.Decoder
+	header    []string
+	positions map[string]int
+	line      int
+}
+
+func newJSONRowReader(path string) (rowReader, error) {
+	header, err := readJSONKeys(path)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error when open file: %v", err)
+	}
+
+	decoder := json.NewDecoder(file)
+	decoder.UseNumber()
+	if err = expectDelim(decoder, '['); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	positions := make(map[string]int)
+	for i, key := range header {
+		positions[key] = i
+	}
+
+	return &jsonRowReader{file: file, decoder: decoder, header: header, positions: positions}, nil
+}
+
+func readJSONKeys(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error when open file: %v", err)
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	decoder.UseNumber()
+	if err = expectDelim(decoder, '['); err != nil {
+		return nil, err
+	}
+
+	var header []string
+	seen := make(map[string]bool)
+	for decoder.More() {
+		err = readJSONObject(decoder, func(key string, value json.RawMessage) error {
+			if !seen[key] {
+				seen[key] = true
+				header = append(header, key)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return header, expectDelim(decoder, ']')
+}
+
+// readJSONObject calls fn with every key of the next object and its undecoded value
+func readJSONObject(decoder *json.Decoder, fn func(key string, value json.RawMessage) error) error {
+	if err := expectDelim(decoder, '{'); err != nil {
+		return err
+	}
+
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return fmt.Errorf("format json tidak valid: %v", err)
+		}
+		key, _ := token.(string)
+
+		var value json.RawMessage
+		if err = decoder.Decode(&value); err != nil {
+			return fmt.Errorf("format json tidak valid: %v", err)
+		}
+
+		if err = fn(key, value); err != nil {
+			return err
+		}
+	}
+
+	return expectDelim(decoder, '}')
+}
+
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return fmt.Errorf("format json tidak valid: %v", err)
+	}
+	if token != delim {
+		return fmt.Errorf("format json tidak valid: %v diharapkan", delim)
+	}
+	return nil
+}
+
+// jsonCell keeps numbers as written so a NIK sent as a number does not lose digits
+func jsonCell(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	default:
+		return "", fmt.Errorf("nilai bertingkat tidak didukung")
+	}
+}
+
+func (r *jsonRowReader) Next() ([]string, error) {
+	if r.line == 0 {
+		r.line++
+		if len(r.header) == 0 {
+			return nil, io.EOF
+		}
+		return r.header, nil
+	}
+
+	if !r.decoder.More() {
+		return nil, io.EOF
+	}
+
+	r.line++
+	row := make([]string, len(r.header))
+	err := readJSONObject(r.decoder, func(key string, raw json.RawMessage) error {
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.UseNumber()
+
+		var value interface{}
+		if err := decoder.Decode(&value); err != nil {
+			return fmt.Errorf("format json tidak valid: %v", err)
+		}
+
+		cell, err := jsonCell(value)
+		if err != nil {
+			return fmt.Errorf("data ke-%d, %s: %v", r.line-1, key, err)
+		}
+		row[r.positions[key]] = cell
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return row, nil
+}
+
+// Line of a json row is the position of the object in the array starting at 1
+func (r *jsonRowReader) Line() int {
+	return r.line - 1
+}
+
+func (r *jsonRowReader) Close() error {
+	return r.file.Close()
+}
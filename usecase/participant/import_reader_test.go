@@ -0,0 +1,150 @@
+/*
+ * Created on 21/10/26 10.20
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package participant
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+func TestDetectDelimiter(t *testing.T) {
+	tests := []struct {
+		name   string
+		sample string
+		want   rune
+	}{
+		{"comma", "Nama,NIK,Status\nBudi,3201014508900001,NOT DONE", ','},
+		{"semicolon", "Nama;NIK;Status\nBudi;3201014508900001;NOT DONE", ';'},
+		{"tab", "Nama\tNIK\tStatus\n", '\t'},
+		{"pipe", "Nama|NIK|Status", '|'},
+		{"quoted separator is ignored", "\"Nama, Lengkap\";NIK;Status\n", ';'},
+		{"only the header line counts", "Nama;NIK\nBudi,Santoso,Jakarta,Bandung", ';'},
+		{"tie goes to the first delimiter", "Nama,NIK;Status", ','},
+		{"no delimiter", "Nama", ','},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectDelimiter([]byte(tt.sample)); got != tt.want {
+				t.Errorf("detectDelimiter() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHeaderKey(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"Nama", "nama"},
+		{"  Kota/Kabupaten   Domisili ", "kota/kabupaten domisili"},
+		{"\ufeffNama", "nama"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := headerKey(tt.header); got != tt.want {
+			t.Errorf("headerKey(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestNewCSVRowReader(t *testing.T) {
+	const content = "Nama;NIK;Kota/Kabupaten\r\nBudi;3201014508900001;Bogor\r\nSiti Aisyah;3201016902960001;Kab. Bogor\r\n"
+
+	encode := func(encoder interface{ String(string) (string, error) }) []byte {
+		encoded, err := encoder.String(content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return []byte(encoded)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"utf-8", []byte(content)},
+		{"utf-8 with bom", append([]byte{0xEF, 0xBB, 0xBF}, content...)},
+		{"utf-16 little endian", encode(unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewEncoder())},
+		{"utf-16 big endian", encode(unicode.UTF16(unicode.BigEndian, unicode.UseBOM).NewEncoder())},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "import.csv")
+			if err := os.WriteFile(path, tt.data, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			reader, delimiter, err := newCSVRowReader(path)
+			if err != nil {
+				t.Fatalf("newCSVRowReader() error = %v", err)
+			}
+			defer reader.Close()
+
+			if delimiter != ';' {
+				t.Errorf("delimiter = %q, want ';'", delimiter)
+			}
+
+			want := [][]string{
+				{"Nama", "NIK", "Kota/Kabupaten"},
+				{"Budi", "3201014508900001", "Bogor"},
+				{"Siti Aisyah", "3201016902960001", "Kab. Bogor"},
+			}
+			for i, cells := range want {
+				got, err := reader.Next()
+				if err != nil {
+					t.Fatalf("row %d: Next() error = %v", i+1, err)
+				}
+				if len(got) != len(cells) {
+					t.Fatalf("row %d: got %q, want %q", i+1, got, cells)
+				}
+				for j := range cells {
+					if got[j] != cells[j] {
+						t.Errorf("row %d cell %d = %q, want %q", i+1, j, got[j], cells[j])
+					}
+				}
+				if reader.Line() != i+1 {
+					t.Errorf("row %d: Line() = %d", i+1, reader.Line())
+				}
+			}
+		})
+	}
+}
+
+func TestNewCSVRowReaderWindows1252(t *testing.T) {
+	data, err := charmap.Windows1252.NewEncoder().String("Nama,Kota\nJosé,Bogor\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "import.csv")
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, _, err := newCSVRowReader(path)
+	if err != nil {
+		t.Fatalf("newCSVRowReader() error = %v", err)
+	}
+	defer reader.Close()
+
+	_, _ = reader.Next()
+	cells, err := reader.Next()
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	if cells[0] != "José" {
+		t.Errorf("name = %q, want %q", cells[0], "José")
+	}
+}
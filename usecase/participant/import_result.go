@@ -0,0 +1,189 @@
+/*
+ * Created on 19/10/26 11.30
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package participant
+
+import (
+	"bumn-sembako-be/helper"
+	"bumn-sembako-be/request"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// resultWriter appends the reported rows of an import to the result file
+type resultWriter interface {
+	Write(row *request.ParticipantInput) error
+	Close() error
+}
+
+// importResult writes the result file in the format of the upload, the file is only created once a row is reported
+type importResult struct {
+	opts     *importOptions
+	filename string
+	writer   resultWriter
+}
+
+func newImportResult(opts *importOptions) *importResult {
+	return &importResult{opts: opts}
+}
+
+func (r *importResult) Write(row *request.ParticipantInput) error {
+	if r.writer == nil {
+		token, err := helper.RandomToken(8)
+		if err != nil {
+			return err
+		}
+		r.filename = time.Now().Format("20060102150405") + "-" + token + "-hasil." + r.opts.format
+
+		tmpFile := "./uploads/" + r.filename
+		switch r.opts.format {
+		case IMPORT_FORMAT_CSV:
+			r.writer, err = newCSVResultWriter(tmpFile, r.opts.delimiter)
+		case IMPORT_FORMAT_JSON:
+			r.writer, err = newJSONResultWriter(tmpFile)
+		default:
+			r.writer, err = newXLSXResultWriter(tmpFile)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return r.writer.Write(row)
+}
+
+// Close finishes the result file and returns its download path, empty when no row was reported
+func (r *importResult) Close() (string, error) {
+	if r.writer == nil {
+		return "", nil
+	}
+
+	err := r.writer.Close()
+	if err != nil {
+		return "", err
+	}
+
+	return "image/" + r.filename, nil
+}
+
+// importRowValues returns the cells of a row in the order of importHeaders
+func importRowValues(row *request.ParticipantInput) []string {
+	return []string{
+		row.Name, row.NIK, row.Gender, row.Phone, row.Address, row.RT, row.RW, row.Provinsi, row.Kota,
+		row.Kecamatan, row.Kelurahan, row.KodePOS, row.ResidenceAddress, row.ResidenceRT, row.ResidenceRW,
+		row.ResidenceProvinsi, row.ResidenceKota, row.ResidenceKecamatan, row.ResidenceKelurahan, row.ResidenceKodePOS,
+		row.Status, row.Note, row.Result,
+	}
+}
+
+type xlsxResultWriter struct {
+	writer *helper.XLSXWriter
+}
+
+func newXLSXResultWriter(tmpFile string) (resultWriter, error) {
+	writer, err := helper.CreateXLSX(tmpFile, "Sheet1")
+	if err != nil {
+		return nil, err
+	}
+
+	err = writer.WriteRow(importHeaders)
+	if err != nil {
+		writer.Close()
+		return nil, err
+	}
+	return &xlsxResultWriter{writer: writer}, nil
+}
+
+func (w *xlsxResultWriter) Write(row *request.ParticipantInput) error {
+	return w.writer.WriteRow(importRowValues(row))
+}
+
+func (w *xlsxResultWriter) Close() error {
+	return w.writer.Close()
+}
+
+type csvResultWriter struct {
+	file   *os.File
+	writer *csv.Writer
+}
+
+func newCSVResultWriter(tmpFile string, delimiter rune) (resultWriter, error) {
+	file, err := os.Create(tmpFile)
+	if err != nil {
+		return nil, err
+	}
+
+	writer := csv.NewWriter(file)
+	if delimiter != 0 {
+		writer.Comma = delimiter
+	}
+
+	err = writer.Write(importHeaders)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &csvResultWriter{file: file, writer: writer}, nil
+}
+
+func (w *csvResultWriter) Write(row *request.ParticipantInput) error {
+	return w.writer.Write(importRowValues(row))
+}
+
+func (w *csvResultWriter) Close() error {
+	w.writer.Flush()
+	err := w.writer.Error()
+	if closeErr := w.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// jsonResultWriter writes the rows as one json array, an element at a time
+type jsonResultWriter struct {
+	file  *os.File
+	count int
+}
+
+func newJSONResultWriter(tmpFile string) (resultWriter, error) {
+	file, err := os.Create(tmpFile)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = file.WriteString("[")
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &jsonResultWriter{file: file}, nil
+}
+
+func (w *jsonResultWriter) Write(row *request.ParticipantInput) error {
+	raw, err := json.MarshalIndent(row, "  ", "  ")
+	if err != nil {
+		return err
+	}
+
+	separator := "\n  "
+	if w.count > 0 {
+		separator = ",\n  "
+	}
+	w.count++
+
+	_, err = w.file.WriteString(separator + string(raw))
+	return err
+}
+
+func (w *jsonResultWriter) Close() error {
+	_, err := w.file.WriteString("\n]\n")
+	if closeErr := w.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
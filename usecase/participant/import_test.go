@@ -0,0 +1,102 @@
+/*
+ * Created on 21/10/26 17.35
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package participant
+
+import (
+	"bumn-sembako-be/model"
+	"bumn-sembako-be/request"
+	"bumn-sembako-be/service/participant"
+	"errors"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// fakeParticipantService fails the ImportBatch calls listed in failBatches, counted from 0
+type fakeParticipantService struct {
+	participant.Service
+	failBatches map[int]bool
+	batches     int
+}
+
+func (s *fakeParticipantService) ImportBatch(inserts []*model.Participant, updates []*model.ParticipantUpdate, batchSize int, audit *model.ParticipantAudit) error {
+	batch := s.batches
+	s.batches++
+	if s.failBatches[batch] {
+		return errors.New("failed import data")
+	}
+	return nil
+}
+
+func TestApplyImportRowsPartial(t *testing.T) {
+	viper.Set("IMPORT_BATCH_SIZE", 2)
+	defer viper.Set("IMPORT_BATCH_SIZE", nil)
+
+	rows := make([]*request.ParticipantInput, 6)
+	ops := make([]*importRowOp, 6)
+	for i := range rows {
+		rows[i] = &request.ParticipantInput{Row: i + 2, Result: model.IMPORT_ROW_INSERTED}
+		ops[i] = &importRowOp{insert: &model.Participant{}}
+	}
+	errs := make([][]*model.ImportError, 6)
+
+	service := &fakeParticipantService{failBatches: map[int]bool{1: true}}
+	u := &usecase{service: service}
+	err := u.applyImportRows(rows, ops, errs, &importOptions{transactionMode: model.IMPORT_MODE_PARTIAL})
+	if err == nil {
+		t.Errorf("applyImportRows() error = nil, want the error of the failed batch")
+	}
+	if service.batches != 3 {
+		t.Errorf("applyImportRows() stored %d batches, want 3", service.batches)
+	}
+
+	// only the rows of the second batch are not stored
+	for i, row := range rows {
+		failed := i == 2 || i == 3
+		if (row.Result == model.IMPORT_ROW_FAILED) != failed {
+			t.Errorf("row %d result = %s", row.Row, row.Result)
+		}
+		if failed && (len(errs[i]) != 1 || errs[i][0].Code != model.IMPORT_ERROR_NOT_SAVED) {
+			t.Errorf("row %d errors = %v, want %s", row.Row, errs[i], model.IMPORT_ERROR_NOT_SAVED)
+		}
+	}
+}
+
+func TestPlanImportRowInsertScope(t *testing.T) {
+	officer := &model.User{Role: "USER", Provinsi: "JAWA BARAT", Kota: "KABUPATEN BOGOR"}
+	tests := []struct {
+		name     string
+		authUser *model.User
+		provinsi string
+		kota     string
+		inserted bool
+	}{
+		{"in the region of the uploader", officer, "Jawa Barat", "Kabupaten Bogor", true},
+		{"other kota", officer, "Jawa Barat", "Kota Bandung", false},
+		{"other provinsi", officer, "DKI Jakarta", "Kabupaten Bogor", false},
+		{"admin", &model.User{Role: "ADMIN"}, "DKI Jakarta", "Kota Jakarta Selatan", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &usecase{regionService: &fakeRegionService{}}
+			row := &request.ParticipantInput{NIK: "3201014508900001", ResidenceProvinsi: tt.provinsi, ResidenceKota: tt.kota}
+			opts := &importOptions{mode: model.IMPORT_MODE_INSERT, authUser: tt.authUser}
+
+			op, importError := u.planImportRow(row, opts)
+			if tt.inserted {
+				if importError != nil || op == nil || op.insert == nil || row.Result != model.IMPORT_ROW_INSERTED {
+					t.Errorf("planImportRow() = %v, %v, want an insert", op, importError)
+				}
+				return
+			}
+			if importError == nil || importError.Code != model.IMPORT_ERROR_OUT_OF_SCOPE {
+				t.Errorf("planImportRow() error = %v, want %s", importError, model.IMPORT_ERROR_OUT_OF_SCOPE)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+/*
+ * Created on 20/10/26 14.05
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package participant
+
+import (
+	"bumn-sembako-be/model"
+	"bumn-sembako-be/request"
+	"bumn-sembako-be/service/region"
+	"bumn-sembako-be/validator"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var ErrNIKRegion = errors.New("kode wilayah pada NIK tidak terdaftar")
+
+// readNIK derives the birth date of the NIK and flags the KTP address levels that differ from the region the NIK
+// was issued in, a NIK that can not be parsed derives nothing. The error is ErrNIKRegion for a region code missing
+// from the region tables, any other error means the region tables could not be read
+func (u *usecase) readNIK(nik, provinsi, kota string) (*time.Time, string, error) {
+	parsed, err := validator.ParseNIK(nik, time.Now())
+	if err != nil {
+		return nil, "", nil
+	}
+	birthDate := parsed.BirthDate
+
+	province, err := u.regionService.FindProvinceByID(parsed.ProvinceCode)
+	if errors.Is(err, region.ErrProvinceNotFound) {
+		return &birthDate, "", fmt.Errorf("%w: provinsi %02d", ErrNIKRegion, parsed.ProvinceCode)
+	}
+	if err != nil {
+		return &birthDate, "", err
+	}
+
+	regency, err := u.regionService.FindRegencyByID(parsed.RegencyCode)
+	if errors.Is(err, region.ErrRegencyNotFound) {
+		return &birthDate, "", fmt.Errorf("%w: kota/kabupaten %04d", ErrNIKRegion, parsed.RegencyCode)
+	}
+	if err != nil {
+		return &birthDate, "", err
+	}
+
+	var flags []string
+	if provinsi != "" && !strings.EqualFold(provinsi, province.Name) {
+		flags = append(flags, model.NIK_FLAG_PROVINSI_MISMATCH)
+	}
+	if kota != "" && !strings.EqualFold(kota, regency.Name) {
+		flags = append(flags, model.NIK_FLAG_KOTA_MISMATCH)
+	}
+
+	return &birthDate, strings.Join(flags, ","), nil
+}
+
+// CountAgeGroups counts the participants per age group of their birth date, every group is returned
+// in order even without participants
+func (u *usecase) CountAgeGroups(req request.ParticipantFilter, authUser *model.User) ([]*model.AgeGroupCount, error) {
+	criteria := make(map[string]interface{})
+	if req.Provinsi != "" {
+		criteria["residence_provinsi"] = req.Provinsi
+	}
+
+	if req.Kota != "" {
+		criteria["residence_kota"] = req.Kota
+	}
+
+	if req.Status != "" {
+		criteria["status"] = req.Status
+	}
+
+	if req.Type != "" {
+		criteria["type"] = req.Type
+	}
+
+	applyScope(criteria, authUser)
+
+	counts, err := u.service.CountAgeGroups(criteria)
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make(map[string]int64)
+	for _, count := range counts {
+		totals[count.AgeGroup] = count.Total
+	}
+
+	var result []*model.AgeGroupCount
+	for _, group := range model.AgeGroups {
+		result = append(result, &model.AgeGroupCount{AgeGroup: group.Label, Total: totals[group.Label]})
+	}
+	result = append(result, &model.AgeGroupCount{AgeGroup: model.AGE_GROUP_UNKNOWN, Total: totals[model.AGE_GROUP_UNKNOWN]})
+	return result, nil
+}
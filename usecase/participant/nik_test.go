@@ -0,0 +1,84 @@
+/*
+ * Created on 21/10/26 16.40
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package participant
+
+import (
+	"bumn-sembako-be/model"
+	"bumn-sembako-be/service/region"
+	"errors"
+	"testing"
+)
+
+// fakeRegionService knows JAWA BARAT and KABUPATEN BOGOR, the methods readNIK does not use are left unimplemented
+type fakeRegionService struct {
+	region.Service
+	err error
+}
+
+func (s *fakeRegionService) FindProvinceByID(id int) (*model.Province, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	if id != 32 {
+		return nil, region.ErrProvinceNotFound
+	}
+	return &model.Province{ID: 32, Name: "JAWA BARAT"}, nil
+}
+
+func (s *fakeRegionService) FindRegencyByID(id int) (*model.Regency, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	if id != 3201 {
+		return nil, region.ErrRegencyNotFound
+	}
+	return &model.Regency{ID: 3201, ProvinceID: 32, Name: "KABUPATEN BOGOR"}, nil
+}
+
+func TestReadNIK(t *testing.T) {
+	errLoad := errors.New("failed load region")
+	tests := []struct {
+		name      string
+		nik       string
+		provinsi  string
+		kota      string
+		lookupErr error
+		flags     string
+		regionErr bool
+		err       bool
+		birthDate bool
+	}{
+		{"matching address", "3201014508900001", "Jawa Barat", "Kabupaten Bogor", nil, "", false, false, true},
+		{"empty address", "3201014508900001", "", "", nil, "", false, false, true},
+		{"other provinsi", "3201014508900001", "DKI Jakarta", "Kabupaten Bogor", nil, model.NIK_FLAG_PROVINSI_MISMATCH, false, false, true},
+		{"other provinsi and kota", "3201014508900001", "DKI Jakarta", "Kota Jakarta Selatan", nil, model.NIK_FLAG_PROVINSI_MISMATCH + "," + model.NIK_FLAG_KOTA_MISMATCH, false, false, true},
+		{"unknown province code", "9901014508900001", "Jawa Barat", "Kabupaten Bogor", nil, "", true, true, true},
+		{"unknown regency code", "3299014508900001", "Jawa Barat", "Kabupaten Bogor", nil, "", true, true, true},
+		{"region tables unreadable", "3201014508900001", "Jawa Barat", "Kabupaten Bogor", errLoad, "", false, true, true},
+		{"unparsable nik", "32010145089000", "Jawa Barat", "Kabupaten Bogor", nil, "", false, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &usecase{regionService: &fakeRegionService{err: tt.lookupErr}}
+
+			birthDate, flags, err := u.readNIK(tt.nik, tt.provinsi, tt.kota)
+			if (err != nil) != tt.err {
+				t.Fatalf("readNIK() error = %v, want error %v", err, tt.err)
+			}
+			if errors.Is(err, ErrNIKRegion) != tt.regionErr {
+				t.Errorf("readNIK() error = %v, want ErrNIKRegion %v", err, tt.regionErr)
+			}
+			if (birthDate != nil) != tt.birthDate {
+				t.Errorf("readNIK() birth date = %v, want birth date %v", birthDate, tt.birthDate)
+			}
+			if flags != tt.flags {
+				t.Errorf("readNIK() flags = %q, want %q", flags, tt.flags)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+/*
+ * Created on 18/10/26 20.40
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package participant
+
+import (
+	"bumn-sembako-be/model"
+	"fmt"
+)
+
+// regionIssue is the first wrong level of an address, column is the import field of that level
+type regionIssue struct {
+	column string
+	code   string
+	note   string
+}
+
+func regionNotFound(column, note, suggestion string) *regionIssue {
+	return &regionIssue{column: column, code: model.IMPORT_ERROR_REGION_NOT_FOUND, note: withSuggestion(note, suggestion)}
+}
+
+func regionMismatch(column, note string) *regionIssue {
+	return &regionIssue{column: column, code: model.IMPORT_ERROR_REGION_MISMATCH, note: note}
+}
+
+// matchRegion walks province → regency → district → village against the cached region dictionary and
+// describes the first level that is wrong, the levels below a wrong one cannot be checked so they are skipped.
+// Names that match closely enough are corrected in place to the official name, otherwise the note carries
+// a suggestion. label tells the KTP address apart from the domicile, an empty name ends the walk without an issue
+func (u *usecase) matchRegion(provinsi, kota, kecamatan, kelurahan *string, label string) *regionIssue {
+	if *provinsi == "" {
+		return nil
+	}
+
+	province, suggestion := u.regionService.MatchProvince(*provinsi)
+	if province == nil {
+		return regionNotFound("provinsi", fmt.Sprintf("Provinsi%s tidak terdaftar", label), suggestion)
+	}
+	*provinsi = province.Name
+
+	if *kota == "" {
+		return nil
+	}
+
+	regency, suggestion := u.regionService.MatchRegency(province.ID, *kota)
+	if regency == nil {
+		if _, err := u.regionService.FindRegency(0, *kota); err == nil {
+			return regionMismatch("kota", fmt.Sprintf("Kota/Kabupaten%s tidak berada di Provinsi %s", label, province.Name))
+		}
+		return regionNotFound("kota", fmt.Sprintf("Kota/Kabupaten%s tidak terdaftar", label), suggestion)
+	}
+	*kota = regency.Name
+
+	if *kecamatan == "" {
+		return nil
+	}
+
+	district, suggestion := u.regionService.MatchDistrict(regency.ID, *kecamatan)
+	if district == nil {
+		if _, err := u.regionService.FindDistrict(0, *kecamatan); err == nil {
+			return regionMismatch("kecamatan", fmt.Sprintf("Kecamatan%s tidak berada di %s", label, regency.Name))
+		}
+		return regionNotFound("kecamatan", fmt.Sprintf("Kecamatan%s tidak terdaftar", label), suggestion)
+	}
+	*kecamatan = district.Name
+
+	if *kelurahan == "" {
+		return nil
+	}
+
+	village, suggestion := u.regionService.MatchVillage(district.ID, *kelurahan)
+	if village == nil {
+		if _, err := u.regionService.FindVillage(0, *kelurahan); err == nil {
+			return regionMismatch("kelurahan", fmt.Sprintf("Kelurahan%s tidak berada di Kecamatan %s", label, district.Name))
+		}
+		return regionNotFound("kelurahan", fmt.Sprintf("Kelurahan%s tidak terdaftar", label), suggestion)
+	}
+	*kelurahan = village.Name
+
+	return nil
+}
+
+func withSuggestion(note, suggestion string) string {
+	if suggestion == "" {
+		return note
+	}
+	return fmt.Sprintf("%s, maksud Anda %s?", note, suggestion)
+}
+
+func firstNonEmpty(value, fallback string) string {
+	if value != "" {
+		return value
+	}
+	return fallback
+}
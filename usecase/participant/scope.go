@@ -0,0 +1,59 @@
+/*
+ * Created on 18/10/26 10.05
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package participant
+
+import (
+	"bumn-sembako-be/model"
+	userUsecase "bumn-sembako-be/usecase/user"
+	"fmt"
+	"strings"
+)
+
+// Scope is based on the domicile address because that is where the distribution happens,
+// so a field officer only sees the beneficiaries living in the region assigned to them.
+
+func bypassScope(authUser *model.User) bool {
+	return authUser == nil || userUsecase.IsAdmin(authUser.Role)
+}
+
+// applyScope ANDs the user's assigned region into the criteria, the assigned region wins over the client filter
+func applyScope(criteria map[string]interface{}, authUser *model.User) {
+	if bypassScope(authUser) {
+		return
+	}
+
+	criteria["residence_provinsi"] = authUser.Provinsi
+	if authUser.Kota != "" {
+		criteria["residence_kota"] = authUser.Kota
+	}
+}
+
+func inScope(provinsi, kota string, authUser *model.User) bool {
+	if bypassScope(authUser) {
+		return true
+	}
+
+	if !strings.EqualFold(provinsi, authUser.Provinsi) {
+		return false
+	}
+
+	return authUser.Kota == "" || strings.EqualFold(kota, authUser.Kota)
+}
+
+// readInScope hides participants outside the user's region as if they do not exist
+func (u *usecase) readInScope(id int, authUser *model.User) (*model.Participant, error) {
+	participant, err := u.service.ReadById(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !inScope(participant.ResidenceProvinsi, participant.ResidenceKota, authUser) {
+		return nil, fmt.Errorf("id is not exists")
+	}
+
+	return participant, nil
+}
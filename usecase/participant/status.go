@@ -0,0 +1,34 @@
+/*
+ * Created on 18/10/26 17.25
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package participant
+
+import (
+	"bumn-sembako-be/model"
+	"errors"
+	"fmt"
+)
+
+var ErrUnknownStatus = errors.New("status tidak dikenal")
+var ErrIllegalTransition = errors.New("perubahan status tidak diizinkan")
+var ErrReasonRequired = errors.New("alasan wajib diisi untuk mengembalikan status")
+
+func checkTransition(from, to string, reason string) error {
+	current, next := model.ParticipantStatus(from), model.ParticipantStatus(to)
+	if !next.IsValid() {
+		return fmt.Errorf("%w: %s", ErrUnknownStatus, to)
+	}
+
+	if !current.CanTransitionTo(next) {
+		return fmt.Errorf("%w: %s ke %s", ErrIllegalTransition, from, to)
+	}
+
+	if current.IsBackward(next) && reason == "" {
+		return ErrReasonRequired
+	}
+
+	return nil
+}
@@ -12,6 +12,7 @@ import (
 	"bumn-sembako-be/request"
 	"bumn-sembako-be/service/participant"
 	"bumn-sembako-be/service/region"
+	"bumn-sembako-be/validator"
 	"encoding/base64"
 	"encoding/csv"
 	"fmt"
@@ -20,8 +21,6 @@ import (
 	"math"
 	"net/http"
 	"os"
-	"regexp"
-	"strings"
 	"time"
 
 	"github.com/360EntSecGroup-Skylar/excelize"
@@ -29,38 +28,61 @@ import (
 )
 
 type Usecase interface {
-	ReadAllBy(req request.ParticipantPaged) (*[]model.Participant, error)
+	ReadAllBy(req request.ParticipantPaged, authUser *model.User) (*[]model.Participant, error)
 	ReadAllLogBy(req request.ParticipantPaged) ([]model.ImportLog, error)
-	Count(req request.ParticipantPaged) int64
+	Count(req request.ParticipantPaged, authUser *model.User) int64
 	CountLogs(req request.ParticipantPaged) int64
-	ReadById(id int) (*model.Participant, error)
-	Update(id int, input request.UpdateParticipant) (*model.Participant, error)
-	Edit(id int, input request.UpdateParticipant) (*model.Participant, error)
-	GetTotalDashboard(req request.ParticipantFilter) (*model.TotalParticipantResponse, error)
-	GetTotalDashboardV2(req request.ParticipantFilter) (*model.TotalParticipantResponse, error)
-	BulkCreate(req request.ImportParticipant) (*model.ImportLog, error)
-	ExportExcel(req request.ParticipantFilter) (string, error)
-	ExportExcelData(req request.ParticipantFilter) (string, error)
-	ExportCSVData(req request.ParticipantFilter) (string, error)
-	Export(input request.Report) ([]*model.ReportPerFile, error)
+	ReadById(id int, authUser *model.User) (*model.Participant, error)
+	Update(id int, input request.UpdateParticipant, authUser *model.User, ip string) (*model.Participant, error)
+	Edit(id int, input request.UpdateParticipant, authUser *model.User, ip string) (*model.Participant, error)
+	GetTotalDashboard(req request.ParticipantFilter, authUser *model.User) (*model.TotalParticipantResponse, error)
+	GetTotalDashboardV2(req request.ParticipantFilter, authUser *model.User) (*model.TotalParticipantResponse, error)
+	BulkCreate(req request.ImportParticipant, authUser *model.User) (*model.ImportLog, error)
+	ExportExcel(req request.ParticipantFilter, authUser *model.User) (string, error)
+	ExportExcelData(req request.ParticipantFilter, authUser *model.User) (string, error)
+	ExportCSVData(req request.ParticipantFilter, authUser *model.User) (string, error)
+	Export(input request.Report, authUser *model.User) ([]*model.ReportPerFile, error)
 	ConvertBase64(path string) template.URL
-	ExportV2(input request.Report) ([]*model.ReportPerFile, error)
-	Reset(id int) (*model.Participant, error)
-	Delete(id int) error
+	ExportV2(input request.Report, authUser *model.User) ([]*model.ReportPerFile, error)
+	Reset(id int, reason string, authUser *model.User, ip string) (*model.Participant, error)
+	Delete(id int, authUser *model.User, ip string) error
 	DeleteBy() error
+	ReadAllHistory(id int, req request.HistoryPaged, authUser *model.User) ([]*model.ParticipantAudit, error)
+	CountHistory(id int) int64
+	ReadAllStatusEvents(id int, authUser *model.User) ([]*model.ParticipantStatusEvent, error)
+	ReadLog(id int, authUser *model.User) (*model.ImportLog, error)
+	DryRunImport(req request.ImportParticipant, authUser *model.User) (*model.ImportDryRun, error)
+	ReadAllImported(id int, req request.ParticipantPaged, authUser *model.User) (*[]model.Participant, error)
+	CountImported(id int, req request.ParticipantPaged, authUser *model.User) int64
+	RollbackImport(id int, authUser *model.User, ip string) (*model.ImportLog, error)
+	ReadAllImportErrors(id int, req request.ImportErrorPaged, authUser *model.User) ([]*model.ImportError, error)
+	CountImportErrors(id int, req request.ImportErrorPaged) int64
+	SummarizeImportErrors(id int, authUser *model.User) ([]*model.ImportErrorCount, error)
+	CountAgeGroups(req request.ParticipantFilter, authUser *model.User) ([]*model.AgeGroupCount, error)
+	ReadAllValidationRule(req request.ValidationRulePaged) ([]*model.ValidationRule, error)
+	ReadEffectiveValidationRules(participantType string) ([]*validator.Rule, error)
+	CreateValidationRule(input request.ValidationRuleInput) (*model.ValidationRule, error)
+	UpdateValidationRule(id int, input request.ValidationRuleInput) (*model.ValidationRule, error)
+	DeleteValidationRule(id int) error
+	ReadAllMapping(req request.ImportMappingPaged, authUser *model.User) ([]*model.ImportMapping, error)
+	CreateMapping(input request.ImportMappingInput, authUser *model.User) (*model.ImportMapping, error)
+	UpdateMapping(id int, input request.ImportMappingInput, authUser *model.User) (*model.ImportMapping, error)
+	DeleteMapping(id int, authUser *model.User) error
 }
 
 type usecase struct {
 	service       participant.Service
 	regionService region.Service
+	imports       chan *importJob
 }
 
 func NewUsecase(service participant.Service, regionService region.Service) Usecase {
-	return &usecase{service: service, regionService: regionService}
-
+	u := &usecase{service: service, regionService: regionService}
+	u.startImportQueue()
+	return u
 }
 
-func (u *usecase) ReadAllBy(req request.ParticipantPaged) (*[]model.Participant, error) {
+func (u *usecase) ReadAllBy(req request.ParticipantPaged, authUser *model.User) (*[]model.Participant, error) {
 	criteria := make(map[string]interface{})
 	if req.Provinsi != "" {
 		criteria["provinsi"] = req.Provinsi
@@ -86,6 +108,8 @@ func (u *usecase) ReadAllBy(req request.ParticipantPaged) (*[]model.Participant,
 		criteria["type"] = req.Type
 	}
 
+	applyScope(criteria, authUser)
+
 	return u.service.ReadAllBy(criteria, req.Search, req.Page, req.Size)
 }
 
@@ -94,11 +118,11 @@ func (u *usecase) ReadAllLogBy(req request.ParticipantPaged) ([]model.ImportLog,
 	return u.service.ReadAllLogBy(criteria, req.Search, req.Page, req.Size)
 }
 
-func (u *usecase) ReadById(id int) (*model.Participant, error) {
-	return u.service.ReadById(id)
+func (u *usecase) ReadById(id int, authUser *model.User) (*model.Participant, error) {
+	return u.readInScope(id, authUser)
 }
 
-func (u *usecase) Count(req request.ParticipantPaged) int64 {
+func (u *usecase) Count(req request.ParticipantPaged, authUser *model.User) int64 {
 	criteria := make(map[string]interface{})
 	if req.Provinsi != "" {
 		criteria["provinsi"] = req.Provinsi
@@ -124,6 +148,8 @@ func (u *usecase) Count(req request.ParticipantPaged) int64 {
 		criteria["type"] = req.Type
 	}
 
+	applyScope(criteria, authUser)
+
 	return u.service.Count(criteria, req.Search)
 }
 
@@ -132,37 +158,61 @@ func (u *usecase) CountLogs(req request.ParticipantPaged) int64 {
 	return u.service.CountLogs(criteria)
 }
 
-func (u *usecase) Update(id int, input request.UpdateParticipant) (*model.Participant, error) {
+func (u *usecase) Update(id int, input request.UpdateParticipant, authUser *model.User, ip string) (*model.Participant, error) {
 	var err error
-	participant, err := u.ReadById(id)
+	participant, err := u.readInScope(id, authUser)
 	if err != nil {
 		return nil, err
 	}
 
-	if input.Status == "PARTIAL_DONE" {
-		req := &request.PartialDone{Status: "PARTIAL_DONE", UpdatedBy: input.UpdatedBy}
+	if input.Status == "" {
+		return participant, nil
+	}
+
+	err = checkTransition(participant.Status, input.Status, input.Reason)
+	if err != nil {
+		return nil, err
+	}
+
+	audit := newAudit(model.AUDIT_UPDATE_STATUS, authUser, ip)
+	audit.Reason = input.Reason
+
+	event := &model.ParticipantStatusEvent{
+		Image:         input.Image,
+		ImagePenerima: input.ImagePenerima,
+		Latitude:      input.Latitude,
+		Longitude:     input.Longitude,
+	}
+
+	switch model.ParticipantStatus(input.Status) {
+	case model.STATUS_NOT_DONE:
+		req := &request.PartialDone{Status: string(model.STATUS_NOT_DONE), UpdatedBy: input.UpdatedBy}
+
+		return u.service.UpdateStatus(participant.ID, req, audit, event)
 
-		updatedParticipant, err := u.service.UpdateStatus(participant.ID, req)
+	case model.STATUS_PARTIAL_DONE:
+		req := &request.PartialDone{Status: string(model.STATUS_PARTIAL_DONE), UpdatedBy: input.UpdatedBy}
+
+		updatedParticipant, err := u.service.UpdateStatus(participant.ID, req, audit, event)
 		if err != nil {
 			return nil, err
 		}
 
 		return updatedParticipant, nil
 
-	} else if input.Status == "REJECTED" {
-		req := &request.PartialDone{Status: "REJECTED", UpdatedBy: input.UpdatedBy}
-
-		participantRejected, err := u.service.UpdateStatus(id, req)
-		if err != nil {
-			return nil, err
+	case model.STATUS_REJECTED:
+		if !inScope(input.ResidenceProvinsi, input.ResidenceKota, authUser) {
+			return nil, fmt.Errorf("domisili penerima pengganti di luar wilayah anda")
 		}
 
-		err = u.service.Delete(participantRejected.ID)
+		// the replacement is checked before the rejected participant is touched
+		err = u.validateParticipant(participant.Type, updateValues(&input))
 		if err != nil {
 			return nil, err
 		}
 
-		nik := u.service.Count(map[string]interface{}{"nik": input.NIK}, "")
+		// the rejected participant is deleted in the same transaction so its NIK may be reused by the replacement
+		nik := u.service.CountNotInId(map[string]interface{}{"nik": input.NIK}, participant.ID)
 		if nik > 0 {
 			return nil, fmt.Errorf("NIK already exists")
 		}
@@ -190,22 +240,27 @@ func (u *usecase) Update(id int, input request.UpdateParticipant) (*model.Partic
 			ResidenceKodePOS:   input.ResidenceKodePOS,
 			Image:              input.Image,
 			ImagePenerima:      input.ImagePenerima,
-			Status:             "DONE",
+			Status:             string(model.STATUS_DONE),
 			UpdatedBy:          input.UpdatedBy,
-			Type:               participantRejected.Type,
+			Type:               participant.Type,
 		}
+		m.BirthDate, m.NIKFlags, _ = u.readNIK(m.NIK, m.Provinsi, m.Kota)
 
-		newParticipant, err := u.service.Create(m)
+		// the photos sent with a rejection belong to the replacement participant
+		audit.Action = model.AUDIT_REJECT
+		req := &request.PartialDone{Status: string(model.STATUS_REJECTED), UpdatedBy: input.UpdatedBy}
+		rejectEvent := &model.ParticipantStatusEvent{Latitude: input.Latitude, Longitude: input.Longitude}
+		newParticipant, err := u.service.RejectAndReplace(participant.ID, req, rejectEvent, m, event, audit)
 		if err != nil {
 			return nil, err
 		}
 
 		return newParticipant, nil
 
-	} else if input.Status == "DONE" {
-		req := &request.PartialDone{Status: "DONE", Image: input.Image, ImagePenerima: input.ImagePenerima, UpdatedBy: input.UpdatedBy}
+	case model.STATUS_DONE:
+		req := &request.PartialDone{Status: string(model.STATUS_DONE), Image: input.Image, ImagePenerima: input.ImagePenerima, UpdatedBy: input.UpdatedBy}
 
-		updateParticipant, err := u.service.UpdateStatus(id, req)
+		updateParticipant, err := u.service.UpdateStatus(id, req, audit, event)
 		if err != nil {
 			return nil, err
 		}
@@ -218,13 +273,65 @@ func (u *usecase) Update(id int, input request.UpdateParticipant) (*model.Partic
 
 }
 
-func (u *usecase) Edit(id int, input request.UpdateParticipant) (*model.Participant, error) {
+func (u *usecase) Edit(id int, input request.UpdateParticipant, authUser *model.User, ip string) (*model.Participant, error) {
 	var err error
-	model, err := u.ReadById(id)
+	audit := newAudit(model.AUDIT_EDIT, authUser, ip)
+
+	// a rejection replaces the participant, that only goes through Update
+	if model.ParticipantStatus(input.Status) == model.STATUS_REJECTED {
+		return nil, fmt.Errorf("%w: status %s hanya melalui penggantian penerima", ErrIllegalTransition, input.Status)
+	}
+
+	model, err := u.readInScope(id, authUser)
 	if err != nil {
 		return nil, err
 	}
 
+	if input.Provinsi != "" || input.Kota != "" || input.Kecamatan != "" || input.Kelurahan != "" {
+		provinsi, kota := firstNonEmpty(input.Provinsi, model.Provinsi), firstNonEmpty(input.Kota, model.Kota)
+		kecamatan, kelurahan := firstNonEmpty(input.Kecamatan, model.Kecamatan), firstNonEmpty(input.Kelurahan, model.Kelurahan)
+		if issue := u.matchRegion(&provinsi, &kota, &kecamatan, &kelurahan, ""); issue != nil {
+			return nil, fmt.Errorf("%s", issue.note)
+		}
+		input.Provinsi, input.Kota, input.Kecamatan, input.Kelurahan = provinsi, kota, kecamatan, kelurahan
+	}
+
+	if input.ResidenceProvinsi != "" || input.ResidenceKota != "" || input.ResidenceKecamatan != "" || input.ResidenceKelurahan != "" {
+		provinsi, kota := firstNonEmpty(input.ResidenceProvinsi, model.ResidenceProvinsi), firstNonEmpty(input.ResidenceKota, model.ResidenceKota)
+		kecamatan, kelurahan := firstNonEmpty(input.ResidenceKecamatan, model.ResidenceKecamatan), firstNonEmpty(input.ResidenceKelurahan, model.ResidenceKelurahan)
+		if issue := u.matchRegion(&provinsi, &kota, &kecamatan, &kelurahan, " Domisili"); issue != nil {
+			return nil, fmt.Errorf("%s", issue.note)
+		}
+		input.ResidenceProvinsi, input.ResidenceKota, input.ResidenceKecamatan, input.ResidenceKelurahan = provinsi, kota, kecamatan, kelurahan
+	}
+
+	residenceProvinsi, residenceKota := input.ResidenceProvinsi, input.ResidenceKota
+	if residenceProvinsi == "" {
+		residenceProvinsi = model.ResidenceProvinsi
+	}
+
+	if residenceKota == "" {
+		residenceKota = model.ResidenceKota
+	}
+
+	if !inScope(residenceProvinsi, residenceKota, authUser) {
+		return nil, fmt.Errorf("domisili penerima di luar wilayah anda")
+	}
+
+	values := editValues(model, &input)
+	err = u.validateParticipant(firstNonEmpty(input.Type, model.Type), values)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Status != "" && input.Status != model.Status {
+		err = checkTransition(model.Status, input.Status, input.Reason)
+		if err != nil {
+			return nil, err
+		}
+		audit.Reason = input.Reason
+	}
+
 	nik := u.service.CountNotInId(map[string]interface{}{"nik": input.NIK}, id)
 	if nik > 0 {
 		return nil, fmt.Errorf("NIK already exists")
@@ -252,10 +359,19 @@ func (u *usecase) Edit(id int, input request.UpdateParticipant) (*model.Particip
 		ResidenceKelurahan: input.ResidenceKelurahan,
 		ResidenceKodePOS:   input.ResidenceKodePOS,
 		Status:             input.Status,
-		UpdatedBy:          model.UpdatedBy,
+		UpdatedBy:          input.UpdatedBy,
 		Type:               input.Type,
 	}
 
+	// the attributes derived from the NIK follow the NIK and KTP address after the edit
+	birthDate, flags, err := u.readNIK(values["nik"], values["provinsi"], values["kota"])
+	if birthDate != nil {
+		req.BirthDate = birthDate
+	}
+	if err == nil {
+		req.NIKFlags = &flags
+	}
+
 	if input.Image != "" {
 		req.Image = input.Image
 	} else {
@@ -268,7 +384,7 @@ func (u *usecase) Edit(id int, input request.UpdateParticipant) (*model.Particip
 		req.ImagePenerima = model.ImagePenerima
 	}
 
-	updatedParticipant, err := u.service.Update(id, req)
+	updatedParticipant, err := u.service.Update(id, req, audit)
 	if err != nil {
 		return nil, err
 	}
@@ -277,7 +393,7 @@ func (u *usecase) Edit(id int, input request.UpdateParticipant) (*model.Particip
 
 }
 
-func (u *usecase) GetTotalDashboard(req request.ParticipantFilter) (*model.TotalParticipantResponse, error) {
+func (u *usecase) GetTotalDashboard(req request.ParticipantFilter, authUser *model.User) (*model.TotalParticipantResponse, error) {
 	var m model.TotalParticipantResponse
 	//var date time.Time
 	var startDate, endDate time.Time
@@ -297,6 +413,8 @@ func (u *usecase) GetTotalDashboard(req request.ParticipantFilter) (*model.Total
 		criteria["type"] = req.Type
 	}
 
+	applyScope(criteria, authUser)
+
 	dataQuota, err := u.service.GetQuota(criteria)
 	if err != nil && err.Error() != gorm.ErrRecordNotFound.Error() {
 		return nil, err
@@ -359,7 +477,7 @@ func (u *usecase) GetTotalDashboard(req request.ParticipantFilter) (*model.Total
 	return &m, nil
 }
 
-func (u *usecase) GetTotalDashboardV2(req request.ParticipantFilter) (*model.TotalParticipantResponse, error) {
+func (u *usecase) GetTotalDashboardV2(req request.ParticipantFilter, authUser *model.User) (*model.TotalParticipantResponse, error) {
 	criteria := make(map[string]interface{})
 	if req.Provinsi != "" {
 		criteria["residence_provinsi"] = req.Provinsi
@@ -381,10 +499,12 @@ func (u *usecase) GetTotalDashboardV2(req request.ParticipantFilter) (*model.Tot
 		criteria["type"] = req.Type
 	}
 
+	applyScope(criteria, authUser)
+
 	return u.service.CountAllStatus(criteria)
 }
 
-func (u *usecase) Export(input request.Report) ([]*model.ReportPerFile, error) {
+func (u *usecase) Export(input request.Report, authUser *model.User) ([]*model.ReportPerFile, error) {
 	r := helper.NewRequestPdf("")
 
 	var reportPerFile []*model.ReportPerFile
@@ -414,6 +534,8 @@ func (u *usecase) Export(input request.Report) ([]*model.ReportPerFile, error) {
 		criteria["type"] = input.Type
 	}
 
+	applyScope(criteria, authUser)
+
 	totalPage := int(math.Ceil(float64(input.TotalSudahMenerima) / float64(limit)))
 
 	for i := 1; i <= totalPage; i++ {
@@ -524,7 +646,7 @@ func (u *usecase) Export(input request.Report) ([]*model.ReportPerFile, error) {
 					HasPrinted: true,
 				}
 
-				_, err = u.service.Update(report.ID, requestInput)
+				_, err = u.service.Update(report.ID, requestInput, nil)
 				if err != nil {
 					return
 				}
@@ -559,376 +681,7 @@ func (u *usecase) Export(input request.Report) ([]*model.ReportPerFile, error) {
 	return reportPerFile, nil
 }
 
-func (u *usecase) BulkCreate(req request.ImportParticipant) (*model.ImportLog, error) {
-	//var m model.ImportLog
-	var successRows, totalRows, failedRows int
-	var status string
-	newFile := excelize.NewFile()
-	randString, err := helper.Randstring(20)
-	if err != nil {
-		return nil, err
-	}
-
-	xlsx, err := excelize.OpenFile(req.TmpPath)
-	if err != nil {
-		return nil, fmt.Errorf("error when open file: %v", err)
-	}
-
-	sheet1Name := "Sheet1"
-
-	newFile.SetSheetName(newFile.GetSheetName(1), sheet1Name)
-	newFile.SetCellValue(sheet1Name, "A1", "Nama")
-	newFile.SetCellValue(sheet1Name, "B1", "NIK")
-	newFile.SetCellValue(sheet1Name, "C1", "Jenis Kelamin")
-	newFile.SetCellValue(sheet1Name, "D1", "No Handphone")
-	newFile.SetCellValue(sheet1Name, "E1", "Alamat Sesuai KTP")
-	newFile.SetCellValue(sheet1Name, "F1", "RT")
-	newFile.SetCellValue(sheet1Name, "G1", "RW")
-	newFile.SetCellValue(sheet1Name, "H1", "Provinsi")
-	newFile.SetCellValue(sheet1Name, "I1", "Kota/Kabupaten")
-	newFile.SetCellValue(sheet1Name, "J1", "Kecamatan")
-	newFile.SetCellValue(sheet1Name, "K1", "Kelurahan")
-	newFile.SetCellValue(sheet1Name, "L1", "Kode Pos")
-	newFile.SetCellValue(sheet1Name, "M1", "Alamat Domisili")
-	newFile.SetCellValue(sheet1Name, "N1", "RT Domisili")
-	newFile.SetCellValue(sheet1Name, "O1", "RW Domisili")
-	newFile.SetCellValue(sheet1Name, "P1", "Provinsi Domisili")
-	newFile.SetCellValue(sheet1Name, "Q1", "Kota/Kabupaten Domisili")
-	newFile.SetCellValue(sheet1Name, "R1", "Kecamatan Domisili")
-	newFile.SetCellValue(sheet1Name, "S1", "Kelurahan Domisili")
-	newFile.SetCellValue(sheet1Name, "T1", "Kode Pos Domisili")
-	newFile.SetCellValue(sheet1Name, "U1", "Status")
-	newFile.SetCellValue(sheet1Name, "V1", "Catatan")
-
-	var rows []*request.ParticipantInput
-	for i := 2; i < 60000; i++ {
-		var note []string
-		row := &request.ParticipantInput{
-			Name:               strings.TrimSpace(xlsx.GetCellValue(sheet1Name, fmt.Sprintf("A%d", i))),
-			NIK:                strings.TrimSpace(xlsx.GetCellValue(sheet1Name, fmt.Sprintf("B%d", i))),
-			Gender:             xlsx.GetCellValue(sheet1Name, fmt.Sprintf("C%d", i)),
-			Phone:              xlsx.GetCellValue(sheet1Name, fmt.Sprintf("D%d", i)),
-			Address:            xlsx.GetCellValue(sheet1Name, fmt.Sprintf("E%d", i)),
-			RT:                 xlsx.GetCellValue(sheet1Name, fmt.Sprintf("F%d", i)),
-			RW:                 xlsx.GetCellValue(sheet1Name, fmt.Sprintf("G%d", i)),
-			Provinsi:           xlsx.GetCellValue(sheet1Name, fmt.Sprintf("H%d", i)),
-			Kota:               xlsx.GetCellValue(sheet1Name, fmt.Sprintf("I%d", i)),
-			Kecamatan:          xlsx.GetCellValue(sheet1Name, fmt.Sprintf("J%d", i)),
-			Kelurahan:          xlsx.GetCellValue(sheet1Name, fmt.Sprintf("K%d", i)),
-			KodePOS:            xlsx.GetCellValue(sheet1Name, fmt.Sprintf("L%d", i)),
-			ResidenceAddress:   xlsx.GetCellValue(sheet1Name, fmt.Sprintf("M%d", i)),
-			ResidenceRT:        xlsx.GetCellValue(sheet1Name, fmt.Sprintf("N%d", i)),
-			ResidenceRW:        xlsx.GetCellValue(sheet1Name, fmt.Sprintf("O%d", i)),
-			ResidenceProvinsi:  xlsx.GetCellValue(sheet1Name, fmt.Sprintf("P%d", i)),
-			ResidenceKota:      xlsx.GetCellValue(sheet1Name, fmt.Sprintf("Q%d", i)),
-			ResidenceKecamatan: xlsx.GetCellValue(sheet1Name, fmt.Sprintf("R%d", i)),
-			ResidenceKelurahan: xlsx.GetCellValue(sheet1Name, fmt.Sprintf("S%d", i)),
-			ResidenceKodePOS:   xlsx.GetCellValue(sheet1Name, fmt.Sprintf("T%d", i)),
-			Status:             xlsx.GetCellValue(sheet1Name, fmt.Sprintf("U%d", i)),
-		}
-
-		if row.Name == "" && row.NIK == "" && row.Gender == "" {
-			break
-		}
-
-		if row.Name == "" {
-			note = append(note, "Nama Kosong \n")
-		} else {
-			trimString := strings.ReplaceAll(row.Name, " ", "")
-			validName := helper.ContainString("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ'.,-", trimString)
-			if validName {
-				note = append(note, "Nama Tidak Sesuai Format \n")
-			}
-
-		}
-
-		if row.NIK == "" {
-			note = append(note, "NIK Kosong \n")
-		}
-
-		if !regexp.MustCompile(`\d`).MatchString(row.NIK) {
-			note = append(note, "NIK terdapat karakter atau simbol karakter \n")
-		} else {
-			if len(strings.TrimLeft(row.NIK, " ")) != 16 {
-				note = append(note, "NIK tidak 16 digit \n")
-			} else {
-				validChar := helper.ContainString("1234567890", row.NIK)
-				if validChar {
-					note = append(note, "NIK Tidak Sesuai Format \n")
-				}
-
-				countBynik := u.service.CheckId(map[string]interface{}{"nik": row.NIK})
-				if countBynik > 0 {
-					note = append(note, "NIK Sudah Terdaftar \n")
-				}
-			}
-		}
-
-		if row.Gender == "" {
-			note = append(note, "Jenis Kelamin Kosong \n")
-		}
-
-		if row.Phone == "" {
-			note = append(note, "No Handphone Kosong \n")
-		} else {
-			if len(row.Phone) > 13 {
-				note = append(note, "No Handphone lebih dari 13 digit \n")
-			}
-
-			if len(row.Phone) < 10 {
-				note = append(note, "No Handphone kurang dari 10 digit \n")
-			}
-
-			if len(row.Phone) > 9 && len(row.Phone) < 14 {
-				validPhone := helper.ContainString("1234567890+", row.Phone)
-				if validPhone {
-					note = append(note, "No Handphone Tidak Sesuai Format \n")
-				}
-
-			}
-
-		}
-
-		if row.Address == "" {
-			note = append(note, "Alamat Kosong \n")
-		}
-
-		if row.RT == "" {
-			note = append(note, "RT Kosong \n")
-		}
-
-		if len(row.RT) > 3 {
-			note = append(note, "RT lebih dari 3 digit \n")
-		}
-
-		if row.RW == "" {
-			note = append(note, "RW Kosong \n")
-		}
-
-		if len(row.RW) > 3 {
-			note = append(note, "RW lebih dari 3 digit \n")
-		}
-
-		if row.Provinsi == "" {
-			note = append(note, "Provinsi Kosong \n")
-		} else {
-			criteria := make(map[string]interface{})
-
-			criteria["name"] = strings.ToUpper(row.Provinsi)
-
-			_, err = u.regionService.ReadProvinceBy(criteria)
-			if err != nil {
-				note = append(note, "Provinsi tidak terdaftar \n")
-			}
-
-		}
-
-		if row.Kota == "" {
-			note = append(note, "Kota/Kabupaten Kosong \n")
-		} else {
-
-			criteria := make(map[string]interface{})
-
-			criteria["name"] = strings.ToUpper(row.Kota)
-
-			_, err = u.regionService.ReadRegencyBy(criteria)
-			if err != nil {
-				note = append(note, "Kota/Kabupaten tidak terdaftar \n")
-			}
-
-		}
-
-		if row.Kecamatan == "" {
-			note = append(note, "Kecamatan Kosong \n")
-		}
-
-		if row.Kelurahan == "" {
-			note = append(note, "Kelurahan Kosong \n")
-		}
-
-		if row.KodePOS == "" {
-			note = append(note, "Kode POS Kosong \n")
-		}
-
-		if row.ResidenceAddress == "" {
-			note = append(note, "Alamat Domisili Kosong \n")
-		}
-
-		if row.ResidenceRT == "" {
-			note = append(note, "RT Domisili Kosong \n")
-		}
-
-		if len(row.ResidenceRT) > 3 {
-			note = append(note, "RT Domisili lebih dari 3 digit \n")
-		}
-
-		if row.ResidenceRW == "" {
-			note = append(note, "RW Domisili Kosong \n")
-		}
-
-		if len(row.RW) > 3 {
-			note = append(note, "RW Domisili lebih dari 3 digit \n")
-		}
-
-		if row.ResidenceProvinsi == "" {
-			note = append(note, "Provinsi Domisili Kosong \n")
-		} else {
-			criteria := make(map[string]interface{})
-
-			criteria["name"] = strings.ToUpper(row.ResidenceProvinsi)
-
-			_, err = u.regionService.ReadProvinceBy(criteria)
-			if err != nil {
-				note = append(note, "Provinsi Domisili tidak terdaftar \n")
-			}
-
-		}
-
-		if row.ResidenceKota == "" {
-			note = append(note, "Kota/Kabupaten Domisili Kosong \n")
-		} else {
-
-			criteria := make(map[string]interface{})
-
-			criteria["name"] = strings.ToUpper(row.ResidenceKota)
-
-			_, err = u.regionService.ReadRegencyBy(criteria)
-			if err != nil {
-				note = append(note, "Kota/Kabupaten Domisili tidak terdaftar \n")
-			}
-
-		}
-
-		if row.ResidenceKecamatan == "" {
-			note = append(note, "Domisili Kecamatan Kosong \n")
-		}
-
-		if row.ResidenceKelurahan == "" {
-			note = append(note, "Domisili Kelurahan \n")
-		}
-
-		if row.ResidenceKodePOS == "" {
-			note = append(note, "Domisili Kode POS Kosong \n")
-		}
-
-		if row.Status == "" {
-			note = append(note, "Status Kosong \n")
-		}
-
-		if len(note) == 0 {
-			newParticipant := &model.Participant{
-				Name:               strings.TrimSpace(row.Name),
-				NIK:                strings.TrimSpace(row.NIK),
-				Gender:             row.Gender,
-				Phone:              row.Phone,
-				Address:            row.Address,
-				RT:                 row.RT,
-				RW:                 row.RW,
-				Provinsi:           strings.ToUpper(row.Provinsi),
-				Kota:               strings.ToUpper(row.Kota),
-				Kecamatan:          strings.ToUpper(row.Kecamatan),
-				Kelurahan:          strings.ToUpper(row.Kelurahan),
-				KodePOS:            row.KodePOS,
-				ResidenceAddress:   row.ResidenceAddress,
-				ResidenceRT:        row.ResidenceRT,
-				ResidenceRW:        row.ResidenceRW,
-				ResidenceProvinsi:  strings.ToUpper(row.ResidenceProvinsi),
-				ResidenceKota:      strings.ToUpper(row.ResidenceKota),
-				ResidenceKecamatan: strings.ToUpper(row.ResidenceKecamatan),
-				ResidenceKelurahan: strings.ToUpper(row.ResidenceKelurahan),
-				ResidenceKodePOS:   row.ResidenceKodePOS,
-				Status:             row.Status,
-				Reference:          randString,
-				Type:               req.Type,
-			}
-
-			_, err = u.service.Create(newParticipant)
-			if err != nil {
-				return nil, err
-			}
-			successRows++
-		} else {
-			index := 2
-			row.Note = strings.Join(note, ",")
-			failedRows++
-			rows = append(rows, row)
-			index++
-
-		}
-
-		totalRows++
-	}
-	if failedRows > 0 {
-
-		for i, row := range rows {
-			newFile.SetCellValue(sheet1Name, fmt.Sprintf("A%d", i+2), row.Name)
-			newFile.SetCellValue(sheet1Name, fmt.Sprintf("B%d", i+2), row.NIK)
-			newFile.SetCellValue(sheet1Name, fmt.Sprintf("C%d", i+2), row.Gender)
-			newFile.SetCellValue(sheet1Name, fmt.Sprintf("D%d", i+2), row.Phone)
-			newFile.SetCellValue(sheet1Name, fmt.Sprintf("E%d", i+2), row.Address)
-			newFile.SetCellValue(sheet1Name, fmt.Sprintf("F%d", i+2), row.RT)
-			newFile.SetCellValue(sheet1Name, fmt.Sprintf("G%d", i+2), row.RW)
-			newFile.SetCellValue(sheet1Name, fmt.Sprintf("H%d", i+2), row.Provinsi)
-			newFile.SetCellValue(sheet1Name, fmt.Sprintf("I%d", i+2), row.Kota)
-			newFile.SetCellValue(sheet1Name, fmt.Sprintf("J%d", i+2), row.Kecamatan)
-			newFile.SetCellValue(sheet1Name, fmt.Sprintf("K%d", i+2), row.Kelurahan)
-			newFile.SetCellValue(sheet1Name, fmt.Sprintf("L%d", i+2), row.KodePOS)
-			newFile.SetCellValue(sheet1Name, fmt.Sprintf("M%d", i+2), row.ResidenceAddress)
-			newFile.SetCellValue(sheet1Name, fmt.Sprintf("N%d", i+2), row.ResidenceRT)
-			newFile.SetCellValue(sheet1Name, fmt.Sprintf("O%d", i+2), row.ResidenceRW)
-			newFile.SetCellValue(sheet1Name, fmt.Sprintf("P%d", i+2), row.ResidenceProvinsi)
-			newFile.SetCellValue(sheet1Name, fmt.Sprintf("Q%d", i+2), row.ResidenceKota)
-			newFile.SetCellValue(sheet1Name, fmt.Sprintf("R%d", i+2), row.ResidenceKecamatan)
-			newFile.SetCellValue(sheet1Name, fmt.Sprintf("S%d", i+2), row.ResidenceKelurahan)
-			newFile.SetCellValue(sheet1Name, fmt.Sprintf("T%d", i+2), row.ResidenceKodePOS)
-			newFile.SetCellValue(sheet1Name, fmt.Sprintf("U%d", i+2), row.Status)
-			newFile.SetCellValue(sheet1Name, fmt.Sprintf("V%d", i+2), row.Note)
-		}
-
-		path := "./uploads"
-		ext := ".xlsx"
-		currentTime := time.Now()
-		filename := currentTime.Format("20060102150405") + ext
-		tmpFile := path + "/" + filename
-		err = newFile.SaveAs(tmpFile)
-		req.Path = "image/" + filename
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	if failedRows > 0 && successRows == 0 {
-		status = "Error All"
-	} else if successRows > 0 && failedRows > 0 {
-		status = "Success With error"
-	} else {
-		status = "Success All"
-	}
-
-	m := &model.ImportLog{
-		FileName:    req.Name,
-		Status:      status,
-		TotalRows:   totalRows,
-		SuccessRows: successRows,
-		FailedRows:  failedRows,
-		Path:        req.Path,
-		UploadedBy:  req.UploadedBy,
-		Type:        req.Type,
-	}
-
-	if successRows > 0 {
-		m.Reference = randString
-	}
-
-	newImportLog, err := u.service.CreateLog(m)
-	if err != nil {
-		return nil, err
-	}
-
-	return newImportLog, nil
-}
-
-func (u *usecase) ExportExcel(req request.ParticipantFilter) (string, error) {
+func (u *usecase) ExportExcel(req request.ParticipantFilter, authUser *model.User) (string, error) {
 	criteria := make(map[string]interface{})
 	xlsx := excelize.NewFile()
 	sheet1Name := "Sheet1"
@@ -953,6 +706,8 @@ func (u *usecase) ExportExcel(req request.ParticipantFilter) (string, error) {
 		criteria["type"] = req.Type
 	}
 
+	applyScope(criteria, authUser)
+
 	rows, err := u.service.CountAllStatusGroup(criteria)
 	if err != nil {
 		return "", err
@@ -990,7 +745,7 @@ func (u *usecase) ExportExcel(req request.ParticipantFilter) (string, error) {
 
 }
 
-func (u *usecase) ExportExcelData(req request.ParticipantFilter) (string, error) {
+func (u *usecase) ExportExcelData(req request.ParticipantFilter, authUser *model.User) (string, error) {
 	criteria := make(map[string]interface{})
 	xlsx := excelize.NewFile()
 	sheet1Name := "Sheet1"
@@ -1038,6 +793,8 @@ func (u *usecase) ExportExcelData(req request.ParticipantFilter) (string, error)
 		criteria["type"] = req.Type
 	}
 
+	applyScope(criteria, authUser)
+
 	rows, err := u.service.ReadAllWithoutPagination(criteria)
 	if err != nil {
 		return "", err
@@ -1082,7 +839,7 @@ func (u *usecase) ExportExcelData(req request.ParticipantFilter) (string, error)
 
 }
 
-func (u *usecase) ExportCSVData(req request.ParticipantFilter) (string, error) {
+func (u *usecase) ExportCSVData(req request.ParticipantFilter, authUser *model.User) (string, error) {
 	criteria := make(map[string]interface{})
 	path := "./uploads"
 	ext := ".csv"
@@ -1135,6 +892,8 @@ func (u *usecase) ExportCSVData(req request.ParticipantFilter) (string, error) {
 		criteria["status"] = req.Status
 	}
 
+	applyScope(criteria, authUser)
+
 	rows, err := u.service.ReadAllWithoutPagination(criteria)
 	if err != nil {
 		return "", err
@@ -1182,7 +941,7 @@ func (u *usecase) ExportCSVData(req request.ParticipantFilter) (string, error) {
 
 }
 
-func (u *usecase) ExportV2(input request.Report) ([]*model.ReportPerFile, error) {
+func (u *usecase) ExportV2(input request.Report, authUser *model.User) ([]*model.ReportPerFile, error) {
 	r := helper.NewRequestPdf("")
 
 	var reportPerFile []*model.ReportPerFile
@@ -1204,6 +963,8 @@ func (u *usecase) ExportV2(input request.Report) ([]*model.ReportPerFile, error)
 		criteria["type"] = input.Type
 	}
 
+	applyScope(criteria, authUser)
+
 	// totalPage := int(math.Ceil(float64(input.TotalSudahMenerima) / float64(limit)))
 
 	// for i := 1; i <= totalPage; i++ {
@@ -1388,12 +1149,30 @@ func (u *usecase) ConvertBase64(path string) template.URL {
 	return template.URL(base64Encoding)
 }
 
-func (u *usecase) Reset(id int) (*model.Participant, error) {
-	return u.service.Reset(id)
+func (u *usecase) Reset(id int, reason string, authUser *model.User, ip string) (*model.Participant, error) {
+	participant, err := u.readInScope(id, authUser)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkTransition(participant.Status, string(model.STATUS_NOT_DONE), reason)
+	if err != nil {
+		return nil, err
+	}
+
+	audit := newAudit(model.AUDIT_RESET, authUser, ip)
+	audit.Reason = reason
+
+	return u.service.Reset(id, audit)
 }
 
-func (u *usecase) Delete(id int) error {
-	return u.service.Delete(id)
+func (u *usecase) Delete(id int, authUser *model.User, ip string) error {
+	_, err := u.readInScope(id, authUser)
+	if err != nil {
+		return err
+	}
+
+	return u.service.Delete(id, newAudit(model.AUDIT_DELETE, authUser, ip))
 }
 
 func (u *usecase) DeleteBy() error {
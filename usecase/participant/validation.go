@@ -0,0 +1,317 @@
+/*
+ * Created on 20/10/26 10.40
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package participant
+
+import (
+	"bumn-sembako-be/helper"
+	"bumn-sembako-be/model"
+	"bumn-sembako-be/request"
+	"bumn-sembako-be/validator"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var ErrInvalidParticipant = errors.New("data peserta tidak valid")
+
+// effectiveRules are the default rules with the overrides for every program and then the overrides of the program,
+// disabled rules included
+func (u *usecase) effectiveRules(participantType string) ([]*validator.Rule, error) {
+	types := []string{""}
+	if participantType != "" {
+		types = append(types, participantType)
+	}
+
+	overrides, err := u.service.ReadAllValidationRuleBy(map[string]interface{}{"type": types})
+	if err != nil {
+		return nil, err
+	}
+
+	var global, program []*validator.Rule
+	for _, override := range overrides {
+		if override.Type == "" {
+			global = append(global, validationRule(override))
+		} else {
+			program = append(program, validationRule(override))
+		}
+	}
+
+	return validator.Merge(validator.Merge(validator.DefaultRules(), global), program), nil
+}
+
+// participantValidator is the validator of the program, a participant is checked against the rules of its type
+func (u *usecase) participantValidator(participantType string) (*validator.Validator, error) {
+	rules, err := u.effectiveRules(participantType)
+	if err != nil {
+		return nil, err
+	}
+	return validator.New(rules)
+}
+
+// validateParticipant checks the values against the rules of the program, the failed rules are joined in the error
+func (u *usecase) validateParticipant(participantType string, values map[string]string) error {
+	v, err := u.participantValidator(participantType)
+	if err != nil {
+		return err
+	}
+
+	var messages []string
+	failedNIK := false
+	for _, issue := range v.Validate(values) {
+		messages = append(messages, issue.Message)
+		failedNIK = failedNIK || issue.Field == "nik"
+	}
+
+	if v.Has(validator.RULE_NIK) && !failedNIK {
+		_, _, err := u.readNIK(values["nik"], values["provinsi"], values["kota"])
+		if errors.Is(err, ErrNIKRegion) {
+			messages = append(messages, err.Error())
+		} else if err != nil {
+			return err
+		}
+	}
+
+	if len(messages) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrInvalidParticipant, strings.Join(messages, ", "))
+}
+
+func validationRule(m *model.ValidationRule) *validator.Rule {
+	var fields []string
+	for _, field := range strings.Split(m.Fields, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+
+	return &validator.Rule{
+		Name:    m.Name,
+		Kind:    m.Kind,
+		Fields:  fields,
+		Min:     m.Min,
+		Max:     m.Max,
+		Chars:   m.Chars,
+		Pattern: m.Pattern,
+		Message: m.Message,
+		Enabled: m.Enabled,
+	}
+}
+
+func inputValues(row *request.ParticipantInput) map[string]string {
+	return map[string]string{
+		"name":                row.Name,
+		"nik":                 row.NIK,
+		"gender":              row.Gender,
+		"phone":               row.Phone,
+		"address":             row.Address,
+		"rt":                  row.RT,
+		"rw":                  row.RW,
+		"provinsi":            row.Provinsi,
+		"kota":                row.Kota,
+		"kecamatan":           row.Kecamatan,
+		"kelurahan":           row.Kelurahan,
+		"kode_pos":            row.KodePOS,
+		"residence_address":   row.ResidenceAddress,
+		"residence_rt":        row.ResidenceRT,
+		"residence_rw":        row.ResidenceRW,
+		"residence_provinsi":  row.ResidenceProvinsi,
+		"residence_kota":      row.ResidenceKota,
+		"residence_kecamatan": row.ResidenceKecamatan,
+		"residence_kelurahan": row.ResidenceKelurahan,
+		"residence_kode_pos":  row.ResidenceKodePOS,
+	}
+}
+
+func updateValues(input *request.UpdateParticipant) map[string]string {
+	return map[string]string{
+		"name":                input.Name,
+		"nik":                 input.NIK,
+		"gender":              input.Gender,
+		"phone":               input.Phone,
+		"address":             input.Address,
+		"rt":                  input.RT,
+		"rw":                  input.RW,
+		"provinsi":            input.Provinsi,
+		"kota":                input.Kota,
+		"kecamatan":           input.Kecamatan,
+		"kelurahan":           input.Kelurahan,
+		"kode_pos":            input.KodePOS,
+		"residence_address":   input.ResidenceAddress,
+		"residence_rt":        input.ResidenceRT,
+		"residence_rw":        input.ResidenceRW,
+		"residence_provinsi":  input.ResidenceProvinsi,
+		"residence_kota":      input.ResidenceKota,
+		"residence_kecamatan": input.ResidenceKecamatan,
+		"residence_kelurahan": input.ResidenceKelurahan,
+		"residence_kode_pos":  input.ResidenceKodePOS,
+	}
+}
+
+// editValues are the values of the participant after an edit, an empty input keeps the stored value
+func editValues(participant *model.Participant, input *request.UpdateParticipant) map[string]string {
+	values := updateValues(&request.UpdateParticipant{
+		Name:               participant.Name,
+		NIK:                participant.NIK,
+		Gender:             participant.Gender,
+		Phone:              participant.Phone,
+		Address:            participant.Address,
+		RT:                 participant.RT,
+		RW:                 participant.RW,
+		Provinsi:           participant.Provinsi,
+		Kota:               participant.Kota,
+		Kecamatan:          participant.Kecamatan,
+		Kelurahan:          participant.Kelurahan,
+		KodePOS:            participant.KodePOS,
+		ResidenceAddress:   participant.ResidenceAddress,
+		ResidenceRT:        participant.ResidenceRT,
+		ResidenceRW:        participant.ResidenceRW,
+		ResidenceProvinsi:  participant.ResidenceProvinsi,
+		ResidenceKota:      participant.ResidenceKota,
+		ResidenceKecamatan: participant.ResidenceKecamatan,
+		ResidenceKelurahan: participant.ResidenceKelurahan,
+		ResidenceKodePOS:   participant.ResidenceKodePOS,
+	})
+
+	for field, value := range updateValues(input) {
+		if value != "" {
+			values[field] = value
+		}
+	}
+	return values
+}
+
+func (u *usecase) ReadAllValidationRule(req request.ValidationRulePaged) ([]*model.ValidationRule, error) {
+	criteria := make(map[string]interface{})
+	if req.Type != "" {
+		criteria["type"] = req.Type
+	}
+
+	return u.service.ReadAllValidationRuleBy(criteria)
+}
+
+// ReadEffectiveValidationRules are the rules a participant of the program is checked against
+func (u *usecase) ReadEffectiveValidationRules(participantType string) ([]*validator.Rule, error) {
+	return u.effectiveRules(participantType)
+}
+
+func (u *usecase) CreateValidationRule(input request.ValidationRuleInput) (*model.ValidationRule, error) {
+	rule := &model.ValidationRule{}
+	err := fillValidationRule(rule, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.service.SaveValidationRule(rule)
+}
+
+func (u *usecase) UpdateValidationRule(id int, input request.ValidationRuleInput) (*model.ValidationRule, error) {
+	rule, err := u.service.ReadValidationRuleById(id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = fillValidationRule(rule, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.service.SaveValidationRule(rule)
+}
+
+func (u *usecase) DeleteValidationRule(id int) error {
+	_, err := u.service.ReadValidationRuleById(id)
+	if err != nil {
+		return err
+	}
+
+	return u.service.DeleteValidationRule(id)
+}
+
+// fillValidationRule validates the input, a rule that overrides a default rule may leave out any attribute and keeps
+// the one of the default rule. The chars or pattern are only required for a new rule or a kind set by the input.
+// A rule is enabled unless the input disables it
+func fillValidationRule(rule *model.ValidationRule, input request.ValidationRuleInput) error {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return fmt.Errorf("nama aturan wajib diisi")
+	}
+
+	var base *validator.Rule
+	for _, r := range validator.DefaultRules() {
+		if r.Name == name {
+			base = r
+		}
+	}
+
+	kind := strings.ToLower(strings.TrimSpace(input.Kind))
+	if kind == "" && base == nil {
+		return fmt.Errorf("jenis aturan wajib diisi untuk aturan baru")
+	}
+	if kind != "" && !validator.IsKind(kind) {
+		return fmt.Errorf("jenis aturan tidak dikenal: %s", input.Kind)
+	}
+	// the chars or pattern of a default rule only carry over while the override keeps its kind
+	inherited := kind == ""
+	if inherited {
+		kind = base.Kind
+	}
+
+	if len(input.Fields) == 0 && base == nil {
+		return fmt.Errorf("kolom aturan wajib diisi untuk aturan baru")
+	}
+
+	var fields []string
+	for _, field := range input.Fields {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if _, ok := validator.Labels[field]; !ok {
+			return fmt.Errorf("kolom tidak dikenal: %s", field)
+		}
+		fields = append(fields, field)
+	}
+
+	// a limit left out keeps the one of the default rule, the pair is checked as it will be merged
+	minLen, maxLen := input.Min, input.Max
+	if base != nil && minLen == 0 {
+		minLen = base.Min
+	}
+	if base != nil && maxLen == 0 {
+		maxLen = base.Max
+	}
+	if input.Min < 0 || input.Max < 0 || (maxLen > 0 && minLen > maxLen) {
+		return fmt.Errorf("batas panjang tidak valid")
+	}
+
+	if kind == validator.KIND_CHARSET && input.Chars == "" && !inherited {
+		return fmt.Errorf("karakter yang diizinkan wajib diisi")
+	}
+
+	if kind == validator.KIND_PATTERN && input.Pattern == "" && !inherited {
+		return fmt.Errorf("pola wajib diisi")
+	}
+
+	if kind == validator.KIND_PATTERN && input.Pattern != "" {
+		_, err := regexp.Compile(input.Pattern)
+		if err != nil {
+			helper.CommonLogger().Error(err)
+			return fmt.Errorf("pola tidak valid: %s", input.Pattern)
+		}
+	}
+
+	rule.Type = strings.TrimSpace(input.Type)
+	rule.Name = name
+	rule.Kind = strings.ToLower(strings.TrimSpace(input.Kind))
+	rule.Fields = strings.Join(fields, ",")
+	rule.Min = input.Min
+	rule.Max = input.Max
+	rule.Chars = input.Chars
+	rule.Pattern = input.Pattern
+	rule.Message = strings.TrimSpace(input.Message)
+	rule.Enabled = input.Enabled == nil || *input.Enabled
+	return nil
+}
@@ -0,0 +1,43 @@
+/*
+ * Created on 21/10/26 17.10
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package participant
+
+import (
+	"bumn-sembako-be/model"
+	"bumn-sembako-be/request"
+	"testing"
+)
+
+func TestFillValidationRule(t *testing.T) {
+	disabled := false
+	tests := []struct {
+		name  string
+		input request.ValidationRuleInput
+		err   bool
+	}{
+		{"disable a default charset rule", request.ValidationRuleInput{Name: "name_charset", Enabled: &disabled}, false},
+		{"change the message of a default charset rule", request.ValidationRuleInput{Name: "nik_digits", Message: "NIK hanya angka"}, false},
+		{"only the max of a default length rule", request.ValidationRuleInput{Name: "phone_length", Max: 15}, false},
+		{"max below the default min", request.ValidationRuleInput{Name: "phone_length", Max: 8}, true},
+		{"default rule set to charset without chars", request.ValidationRuleInput{Name: "phone_length", Kind: "charset"}, true},
+		{"default charset rule with its kind repeated", request.ValidationRuleInput{Name: "name_charset", Kind: "charset"}, true},
+		{"new charset rule without chars", request.ValidationRuleInput{Name: "kota_charset", Kind: "charset", Fields: []string{"kota"}}, true},
+		{"new pattern rule without pattern", request.ValidationRuleInput{Name: "kota_pattern", Kind: "pattern", Fields: []string{"kota"}}, true},
+		{"new pattern rule with an invalid pattern", request.ValidationRuleInput{Name: "kota_pattern", Kind: "pattern", Fields: []string{"kota"}, Pattern: "("}, true},
+		{"new pattern rule", request.ValidationRuleInput{Name: "kota_pattern", Kind: "pattern", Fields: []string{"kota"}, Pattern: "^[A-Z ]+$"}, false},
+		{"new rule without kind", request.ValidationRuleInput{Name: "kota_pattern", Fields: []string{"kota"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := fillValidationRule(&model.ValidationRule{}, tt.input)
+			if (err != nil) != tt.err {
+				t.Errorf("fillValidationRule() error = %v, want error %v", err, tt.err)
+			}
+		})
+	}
+}
@@ -17,6 +17,7 @@ type Usecase interface {
 	ReadAllRegencyBy(req request.RegionPaged) ([]*model.Regency, error)
 	ReadAllDistrictBy(req request.RegionPaged) ([]*model.District, error)
 	ReadAllVillageBy(req request.RegionPaged) ([]*model.Village, error)
+	RefreshCache() error
 }
 
 type usecase struct {
@@ -29,30 +30,24 @@ func NewUsecase(service region.Service) Usecase {
 }
 
 func (u *usecase) ReadAllProvinceBy(req request.RegionPaged) ([]*model.Province, error) {
-	criteria := make(map[string]interface{})
-	return u.service.ReadAllProvinceBy(criteria, req.Search)
+	return u.service.CachedProvinces(req.Search)
 
 }
 
 func (u *usecase) ReadAllRegencyBy(req request.RegionPaged) ([]*model.Regency, error) {
-	criteria := make(map[string]interface{})
-	criteria["province_id"] = req.ProvinceID
-
-	return u.service.ReadAllRegencyBy(criteria, req.Search)
+	return u.service.CachedRegencies(req.ProvinceID, req.Search)
 
 }
 
 func (u *usecase) ReadAllDistrictBy(req request.RegionPaged) ([]*model.District, error) {
-	criteria := make(map[string]interface{})
-	criteria["regency_id"] = req.RegencyID
-
-	return u.service.ReadAllDistrictBy(criteria, req.Search)
+	return u.service.CachedDistricts(req.RegencyID, req.Search)
 }
 
 func (u *usecase) ReadAllVillageBy(req request.RegionPaged) ([]*model.Village, error) {
-	criteria := make(map[string]interface{})
-	criteria["district_id"] = req.DistrictID
+	return u.service.CachedVillages(req.DistrictID, req.Search)
 
-	return u.service.ReadAllVillageBy(criteria, req.Search)
+}
 
+func (u *usecase) RefreshCache() error {
+	return u.service.RefreshCache()
 }
@@ -0,0 +1,36 @@
+/*
+ * Created on 18/10/26 10.48
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package user
+
+import (
+	"errors"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+var ErrInvalidCredential = errors.New("username atau password salah")
+var ErrAccountLocked = errors.New("akun terkunci karena terlalu banyak percobaan login gagal")
+
+const defaultLoginMaxAttempts = 5
+const defaultLoginLockMinutes = 15
+
+// loginMaxAttempts is the number of failed logins before the account is locked, LOGIN_MAX_ATTEMPTS in .env
+func loginMaxAttempts() int64 {
+	if v := viper.GetInt64("LOGIN_MAX_ATTEMPTS"); v > 0 {
+		return v
+	}
+	return defaultLoginMaxAttempts
+}
+
+// loginLockDuration is how long a locked account stays locked, LOGIN_LOCK_MINUTES in .env
+func loginLockDuration() time.Duration {
+	if v := viper.GetInt("LOGIN_LOCK_MINUTES"); v > 0 {
+		return time.Duration(v) * time.Minute
+	}
+	return defaultLoginLockMinutes * time.Minute
+}
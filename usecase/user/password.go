@@ -0,0 +1,109 @@
+/*
+ * Created on 18/10/26 15.02
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package user
+
+import (
+	"bumn-sembako-be/helper"
+	"bumn-sembako-be/request"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/viper"
+)
+
+var ErrPasswordChangeRequired = errors.New("password harus diganti sebelum melanjutkan")
+
+const defaultPasswordHistory = 5
+
+// passwordHistory is how many previous passwords can not be reused, PASSWORD_HISTORY in .env
+func passwordHistory() int {
+	if v := viper.GetInt("PASSWORD_HISTORY"); v > 0 {
+		return v
+	}
+	return defaultPasswordHistory
+}
+
+// ChangePassword verifies the old password, then logs out every other session of the user
+func (u *usecase) ChangePassword(id int, sessionID int, input request.UpdateUser) error {
+	getUser, err := u.service.ReadById(id)
+	if err != nil {
+		return err
+	}
+
+	if !helper.ComparePassword(getUser.Password, input.OldPassword) {
+		return fmt.Errorf("password lama tidak sesuai")
+	}
+
+	err = helper.ValidatePassword(input.Password)
+	if err != nil {
+		return err
+	}
+
+	histories, err := u.service.ReadPasswordHistory(id, passwordHistory())
+	if err != nil {
+		return err
+	}
+
+	if helper.ComparePassword(getUser.Password, input.Password) {
+		return fmt.Errorf("password baru tidak boleh sama dengan %d password terakhir", passwordHistory())
+	}
+
+	for _, history := range histories {
+		if helper.ComparePassword(history.Password, input.Password) {
+			return fmt.Errorf("password baru tidak boleh sama dengan %d password terakhir", passwordHistory())
+		}
+	}
+
+	helper.HashPassword(&input.Password)
+
+	err = u.service.UpdatePassword(id, input.Password, false)
+	if err != nil {
+		return err
+	}
+
+	sessions, err := u.service.ReadActiveSessions(id)
+	if err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		if session.ID == sessionID {
+			continue
+		}
+
+		err = u.service.RevokeSession(session.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ResetPassword gives the user a one-time password that has to be changed on the next login
+func (u *usecase) ResetPassword(id int) (string, error) {
+	_, err := u.service.ReadById(id)
+	if err != nil {
+		return "", err
+	}
+
+	temporaryPassword, err := helper.TemporaryPassword()
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		return "", err
+	}
+
+	password := temporaryPassword
+	helper.HashPassword(&password)
+
+	err = u.service.ResetPassword(id, password)
+	if err != nil {
+		return "", err
+	}
+
+	return temporaryPassword, nil
+}
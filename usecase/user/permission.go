@@ -0,0 +1,94 @@
+/*
+ * Created on 18/10/26 09.20
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package user
+
+const ROLE_ADMIN = "ADMIN"
+const ROLE_SUPERADMIN = "SUPERADMIN"
+
+const PERMISSION_USER_MANAGE = "user:manage"
+const PERMISSION_PARTICIPANT_VIEW = "participant:view"
+const PERMISSION_PARTICIPANT_UPDATE = "participant:update"
+const PERMISSION_PARTICIPANT_EDIT = "participant:edit"
+const PERMISSION_PARTICIPANT_RESET = "participant:reset"
+const PERMISSION_PARTICIPANT_DELETE = "participant:delete"
+const PERMISSION_PARTICIPANT_IMPORT = "participant:import"
+const PERMISSION_REPORT_EXPORT = "report:export"
+const PERMISSION_REGION_MANAGE = "region:manage"
+const PERMISSION_VALIDATION_MANAGE = "validation:manage"
+
+// rolePermissions is the permission matrix of every role against every route group
+var rolePermissions = map[string][]string{
+	ROLE_SUPERADMIN: {
+		PERMISSION_USER_MANAGE,
+		PERMISSION_PARTICIPANT_VIEW,
+		PERMISSION_PARTICIPANT_UPDATE,
+		PERMISSION_PARTICIPANT_EDIT,
+		PERMISSION_PARTICIPANT_RESET,
+		PERMISSION_PARTICIPANT_DELETE,
+		PERMISSION_PARTICIPANT_IMPORT,
+		PERMISSION_REPORT_EXPORT,
+		PERMISSION_REGION_MANAGE,
+		PERMISSION_VALIDATION_MANAGE,
+	},
+	ROLE_ADMIN: {
+		PERMISSION_USER_MANAGE,
+		PERMISSION_PARTICIPANT_VIEW,
+		PERMISSION_PARTICIPANT_UPDATE,
+		PERMISSION_PARTICIPANT_EDIT,
+		PERMISSION_PARTICIPANT_RESET,
+		PERMISSION_PARTICIPANT_DELETE,
+		PERMISSION_PARTICIPANT_IMPORT,
+		PERMISSION_REPORT_EXPORT,
+		PERMISSION_REGION_MANAGE,
+		PERMISSION_VALIDATION_MANAGE,
+	},
+	ROLE_YAYASAN: {
+		PERMISSION_PARTICIPANT_VIEW,
+		PERMISSION_PARTICIPANT_UPDATE,
+		PERMISSION_PARTICIPANT_EDIT,
+		PERMISSION_PARTICIPANT_IMPORT,
+		PERMISSION_REPORT_EXPORT,
+	},
+	ROLE: {
+		PERMISSION_PARTICIPANT_VIEW,
+		PERMISSION_PARTICIPANT_UPDATE,
+		PERMISSION_REPORT_EXPORT,
+	},
+}
+
+// Permissions returns every permission granted to the role, empty for unknown roles
+func Permissions(role string) []string {
+	permissions, ok := rolePermissions[role]
+	if !ok {
+		return []string{}
+	}
+	return permissions
+}
+
+func HasPermission(role, permission string) bool {
+	for _, p := range Permissions(role) {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+func IsAdmin(role string) bool {
+	return role == ROLE_ADMIN || role == ROLE_SUPERADMIN
+}
+
+// CanAssignRole only lets a SUPERADMIN hand out administrator roles
+func CanAssignRole(actorRole, role string) bool {
+	if _, ok := rolePermissions[role]; !ok {
+		return false
+	}
+	if IsAdmin(role) {
+		return actorRole == ROLE_SUPERADMIN
+	}
+	return IsAdmin(actorRole)
+}
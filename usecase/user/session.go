@@ -0,0 +1,128 @@
+/*
+ * Created on 18/10/26 13.25
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package user
+
+import (
+	"bumn-sembako-be/helper"
+	"bumn-sembako-be/model"
+	"errors"
+	"time"
+)
+
+var ErrSessionRevoked = errors.New("sesi sudah berakhir, silahkan login kembali")
+
+func (u *usecase) CreateSession(user *model.User, userAgent, ip string) (*model.AuthToken, error) {
+	refreshToken, err := helper.RandomToken(32)
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		return nil, err
+	}
+
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+
+	session, err := u.service.CreateSession(&model.Session{
+		UserID:           user.ID,
+		RefreshTokenHash: helper.HashToken(refreshToken),
+		UserAgent:        userAgent,
+		IP:               ip,
+		ExpiresAt:        time.Now().Add(helper.RefreshTokenTTL()),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	token, expiresAt := helper.GenerateToken(user, session.ID)
+
+	return &model.AuthToken{
+		Token:            token,
+		ExpiresAt:        expiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: session.ExpiresAt,
+	}, nil
+}
+
+// Refresh exchanges a refresh token for a new access token and rotates the refresh token
+func (u *usecase) Refresh(refreshToken string) (*model.AuthToken, *model.User, error) {
+	hash := helper.HashToken(refreshToken)
+	session, err := u.service.ReadSessionByRefreshToken(hash)
+	if err != nil {
+		// a refresh token that was already rotated is replayed, the session is revoked as it may have been stolen
+		replayed, err := u.service.ReadSessionByPreviousRefreshToken(hash)
+		if err == nil {
+			helper.CommonLogger().WithField("session_id", replayed.ID).Warn("rotated refresh token replayed, session revoked")
+			err = u.service.RevokeSession(replayed.ID)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		return nil, nil, ErrSessionRevoked
+	}
+
+	if session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		return nil, nil, ErrSessionRevoked
+	}
+
+	user, err := u.service.ReadById(session.UserID)
+	if err != nil {
+		return nil, nil, ErrSessionRevoked
+	}
+
+	newRefreshToken, err := helper.RandomToken(32)
+	if err != nil {
+		helper.CommonLogger().Error(err)
+		return nil, nil, err
+	}
+
+	expiresAt := time.Now().Add(helper.RefreshTokenTTL())
+	rotated, err := u.service.RotateSession(session.ID, hash, helper.HashToken(newRefreshToken), expiresAt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the refresh token was used twice, the session is revoked as it may have been stolen
+	if !rotated {
+		helper.CommonLogger().WithField("session_id", session.ID).Warn("refresh token reused, session revoked")
+		err = u.service.RevokeSession(session.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, ErrSessionRevoked
+	}
+
+	token, tokenExpiresAt := helper.GenerateToken(user, session.ID)
+
+	return &model.AuthToken{
+		Token:            token,
+		ExpiresAt:        tokenExpiresAt,
+		RefreshToken:     newRefreshToken,
+		RefreshExpiresAt: expiresAt,
+	}, user, nil
+}
+
+// ValidateSession is checked on every authenticated request so revoked sessions lose access immediately
+func (u *usecase) ValidateSession(sessionID, userID int) error {
+	session, err := u.service.ReadSessionById(sessionID)
+	if err != nil {
+		return ErrSessionRevoked
+	}
+
+	if session.UserID != userID || session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		return ErrSessionRevoked
+	}
+
+	return nil
+}
+
+func (u *usecase) Logout(sessionID int) error {
+	return u.service.RevokeSession(sessionID)
+}
+
+func (u *usecase) LogoutAll(userID int) error {
+	return u.service.RevokeUserSessions(userID)
+}
@@ -12,7 +12,9 @@ import (
 	"bumn-sembako-be/request"
 	"bumn-sembako-be/service/user"
 	"fmt"
+	"time"
 
+	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
@@ -23,12 +25,20 @@ type Usecase interface {
 	Register(user request.Register) (*model.User, error)
 	RegisterYayasan(user request.Register) (*model.User, error)
 	Create(user request.User) (*model.User, error)
-	Login(user request.Login) (*model.User, error)
+	Login(user request.Login, ip string) (*model.User, error)
 	ReadAllBy(req request.UserPaged) (*[]model.User, error)
 	Count(req request.UserPaged) int64
 	ReadById(id int) (*model.User, error)
 	Update(id int, user *model.User) (*model.User, error)
 	Delete(id int) error
+	Unlock(id int) (*model.User, error)
+	CreateSession(user *model.User, userAgent, ip string) (*model.AuthToken, error)
+	Refresh(refreshToken string) (*model.AuthToken, *model.User, error)
+	ValidateSession(sessionID, userID int) error
+	Logout(sessionID int) error
+	LogoutAll(userID int) error
+	ChangePassword(id int, sessionID int, input request.UpdateUser) error
+	ResetPassword(id int) (string, error)
 	ReadAllOrganization() (*[]model.Organization, error)
 	ReadAllOrganizationEO() (*[]model.Organization, error)
 	ReadAllOrganizationYayasan() (*[]model.Organization, error)
@@ -55,6 +65,11 @@ func (u *usecase) Create(user request.User) (*model.User, error) {
 		return nil, fmt.Errorf("username sudah terdaftar, silahkan menggunakan username lain")
 	}
 
+	err = helper.ValidatePassword(user.Password)
+	if err != nil {
+		return nil, err
+	}
+
 	helper.HashPassword(&user.Password)
 
 	newUser := &model.User{
@@ -66,6 +81,8 @@ func (u *usecase) Create(user request.User) (*model.User, error) {
 		Kota:           user.Kota,
 		Provinsi:       user.Provinsi,
 		RetryAttempts:  0,
+		// accounts created by an administrator start with a password the user has to replace
+		MustChangePassword: true,
 	}
 
 	m, err := u.service.Create(newUser)
@@ -74,6 +91,11 @@ func (u *usecase) Create(user request.User) (*model.User, error) {
 		return nil, err
 	}
 
+	err = u.service.CreatePasswordHistory(m.ID, m.Password)
+	if err != nil {
+		return nil, err
+	}
+
 	m.Password = ""
 
 	return m, nil
@@ -91,6 +113,11 @@ func (u *usecase) Register(user request.Register) (*model.User, error) {
 		return nil, fmt.Errorf("username sudah terdaftar, silahkan menggunakan username lain")
 	}
 
+	err = helper.ValidatePassword(user.Password)
+	if err != nil {
+		return nil, err
+	}
+
 	helper.HashPassword(&user.Password)
 
 	newUser := &model.User{
@@ -109,6 +136,11 @@ func (u *usecase) Register(user request.Register) (*model.User, error) {
 		return nil, err
 	}
 
+	err = u.service.CreatePasswordHistory(m.ID, m.Password)
+	if err != nil {
+		return nil, err
+	}
+
 	m.Password = ""
 
 	return m, nil
@@ -126,6 +158,11 @@ func (u *usecase) RegisterYayasan(user request.Register) (*model.User, error) {
 		return nil, fmt.Errorf("username sudah terdaftar, silahkan menggunakan username lain")
 	}
 
+	err = helper.ValidatePassword(user.Password)
+	if err != nil {
+		return nil, err
+	}
+
 	helper.HashPassword(&user.Password)
 
 	newUser := &model.User{
@@ -144,19 +181,60 @@ func (u *usecase) RegisterYayasan(user request.Register) (*model.User, error) {
 		return nil, err
 	}
 
+	err = u.service.CreatePasswordHistory(m.ID, m.Password)
+	if err != nil {
+		return nil, err
+	}
+
 	m.Password = ""
 
 	return m, nil
 
 }
 
-func (u *usecase) Login(user request.Login) (*model.User, error) {
+func (u *usecase) Login(user request.Login, ip string) (*model.User, error) {
 
 	getUser, err := u.service.ReadByUsername(user.Username)
 	if err != nil {
 		helper.CommonLogger().Error(err)
-		return nil, err
+		return nil, ErrInvalidCredential
+	}
+
+	now := time.Now()
+	if getUser.LockedUntil != nil && now.Before(*getUser.LockedUntil) {
+		return nil, fmt.Errorf("%w, coba lagi setelah %s", ErrAccountLocked, getUser.LockedUntil.Format("15:04"))
+	}
+
+	if !helper.ComparePassword(getUser.Password, user.Password) {
+		lockedUntil := now.Add(loginLockDuration())
+		attempts, locked, err := u.service.FailLoginAttempt(getUser.ID, loginMaxAttempts(), lockedUntil)
+		if err != nil {
+			return nil, err
+		}
+		if !locked {
+			return nil, ErrInvalidCredential
+		}
+
+		helper.CommonLogger().WithFields(logrus.Fields{
+			"user_id":      getUser.ID,
+			"username":     getUser.Username,
+			"ip":           ip,
+			"attempts":     attempts,
+			"locked_until": lockedUntil,
+		}).Warn("account locked after failed login attempts")
+
+		return nil, fmt.Errorf("%w, coba lagi setelah %s", ErrAccountLocked, lockedUntil.Format("15:04"))
 	}
+
+	if getUser.RetryAttempts > 0 || getUser.LockedUntil != nil {
+		err = u.service.UpdateLoginAttempt(getUser.ID, 0, nil)
+		if err != nil {
+			return nil, err
+		}
+		getUser.RetryAttempts = 0
+		getUser.LockedUntil = nil
+	}
+
 	return getUser, nil
 }
 
@@ -191,6 +269,14 @@ func (u *usecase) Delete(id int) error {
 	return u.service.Delete(id)
 }
 
+func (u *usecase) Unlock(id int) (*model.User, error) {
+	err := u.service.UpdateLoginAttempt(id, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	return u.service.ReadById(id)
+}
+
 func (u *usecase) ReadAllOrganization() (*[]model.Organization, error) {
 	return u.service.ReadAllOrganization()
 }
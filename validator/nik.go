@@ -0,0 +1,113 @@
+/*
+ * Created on 18/10/26 05.41
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package validator
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const GENDER_MALE = "L"
+const GENDER_FEMALE = "P"
+
+// NIK_MAX_AGE is the oldest age in years a birth date of a NIK may give
+const NIK_MAX_AGE = 120
+
+var ErrNIKLength = errors.New("NIK tidak 16 digit")
+var ErrNIKDigits = errors.New("NIK terdapat karakter atau simbol karakter")
+var ErrNIKBirthDate = errors.New("Tanggal lahir pada NIK tidak valid")
+
+// NIK is what a NIK encodes, the region codes are the Kemendagri codes of the place the NIK was issued
+type NIK struct {
+	ProvinceCode int       `json:"province_code"`
+	RegencyCode  int       `json:"regency_code"`
+	BirthDate    time.Time `json:"birth_date"`
+	Gender       string    `json:"gender"`
+}
+
+// ParseNIK reads the 16 digits PPKKCC DDMMYY SSSS of a NIK, a woman has 40 added to her day of birth.
+// The two digit year is taken in the current century unless that puts the birth date after today.
+// A birth date after today or more than NIK_MAX_AGE years before it is invalid
+func ParseNIK(nik string, today time.Time) (*NIK, error) {
+	nik = strings.TrimSpace(nik)
+	if len(nik) != 16 {
+		return nil, ErrNIKLength
+	}
+	for _, char := range nik {
+		if char < '0' || char > '9' {
+			return nil, ErrNIKDigits
+		}
+	}
+
+	number := func(from, to int) int {
+		n, _ := strconv.Atoi(nik[from:to])
+		return n
+	}
+
+	parsed := &NIK{
+		ProvinceCode: number(0, 2),
+		RegencyCode:  number(0, 4),
+		Gender:       GENDER_MALE,
+	}
+
+	day, month, year := number(6, 8), number(8, 10), number(10, 12)
+	if day > 40 {
+		day -= 40
+		parsed.Gender = GENDER_FEMALE
+	}
+
+	if day < 1 || day > 31 || month < 1 || month > 12 {
+		return nil, ErrNIKBirthDate
+	}
+
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	birthDate := time.Date(2000+year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if birthDate.After(today) {
+		birthDate = birthDate.AddDate(-100, 0, 0)
+	}
+
+	// time.Date rolls 31 April over to 1 May
+	if birthDate.Day() != day {
+		return nil, ErrNIKBirthDate
+	}
+
+	if birthDate.After(today) || birthDate.Before(today.AddDate(-NIK_MAX_AGE, 0, 0)) {
+		return nil, ErrNIKBirthDate
+	}
+
+	parsed.BirthDate = birthDate
+	return parsed, nil
+}
+
+// NormalizeGender maps the usual ways to write a gender to L or P, empty when the value is not recognized
+func NormalizeGender(value string) string {
+	switch strings.ToUpper(strings.Join(strings.Fields(strings.ReplaceAll(value, "-", " ")), " ")) {
+	case "L", "LK", "LAKI", "LAKI LAKI", "PRIA", "M", "MALE":
+		return GENDER_MALE
+	case "P", "PR", "PEREMPUAN", "WANITA", "W", "F", "FEMALE":
+		return GENDER_FEMALE
+	}
+	return ""
+}
+
+// checkNIK parses the NIK and compares the gender it encodes with the gender field
+func checkNIK(values map[string]string, value string) (string, string) {
+	parsed, err := ParseNIK(value, time.Now())
+	if errors.Is(err, ErrNIKBirthDate) {
+		return CODE_NIK_BIRTH_DATE, err.Error()
+	}
+	if err != nil {
+		return CODE_INVALID_FORMAT, err.Error()
+	}
+
+	if gender := NormalizeGender(values["gender"]); gender != "" && gender != parsed.Gender {
+		return CODE_NIK_GENDER_MISMATCH, "Jenis Kelamin tidak sesuai dengan NIK"
+	}
+	return "", ""
+}
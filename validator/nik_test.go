@@ -0,0 +1,131 @@
+/*
+ * Created on 21/10/26 14.30
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package validator
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseNIK(t *testing.T) {
+	today := time.Date(2026, 10, 18, 15, 4, 5, 0, time.Local)
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name      string
+		nik       string
+		birthDate time.Time
+		gender    string
+		err       error
+	}{
+		{"male", "3201010508900001", date(1990, 8, 5), GENDER_MALE, nil},
+		{"female has day plus 40", "3201014508900001", date(1990, 8, 5), GENDER_FEMALE, nil},
+		{"female on the 31st", "3201017112990001", date(1999, 12, 31), GENDER_FEMALE, nil},
+		{"current century", "3201010101100001", date(2010, 1, 1), GENDER_MALE, nil},
+		{"born today", "3201011810260001", date(2026, 10, 18), GENDER_MALE, nil},
+		{"tomorrow rolls over to the previous century", "3201011910260001", date(1926, 10, 19), GENDER_MALE, nil},
+		{"previous century", "3201012910260001", date(1926, 10, 29), GENDER_MALE, nil},
+		{"leap day", "3201016902960001", date(1996, 2, 29), GENDER_FEMALE, nil},
+		{"leap day of a leap year 2000", "3201012902000001", date(2000, 2, 29), GENDER_MALE, nil},
+		{"surrounding spaces", " 3201010508900001 ", date(1990, 8, 5), GENDER_MALE, nil},
+		{"31 april", "3201013104900001", time.Time{}, "", ErrNIKBirthDate},
+		{"31 april female", "3201017104900001", time.Time{}, "", ErrNIKBirthDate},
+		{"29 february of a common year", "3201012902970001", time.Time{}, "", ErrNIKBirthDate},
+		{"29 february of a common year in the previous century", "3201012902270001", time.Time{}, "", ErrNIKBirthDate},
+		{"day 0", "3201010008900001", time.Time{}, "", ErrNIKBirthDate},
+		{"day 32", "3201013208900001", time.Time{}, "", ErrNIKBirthDate},
+		{"day 40", "3201014008900001", time.Time{}, "", ErrNIKBirthDate},
+		{"day 72", "3201017208900001", time.Time{}, "", ErrNIKBirthDate},
+		{"month 0", "3201010500900001", time.Time{}, "", ErrNIKBirthDate},
+		{"month 13", "3201010513900001", time.Time{}, "", ErrNIKBirthDate},
+		{"too short", "320101050890001", time.Time{}, "", ErrNIKLength},
+		{"too long", "32010105089000011", time.Time{}, "", ErrNIKLength},
+		{"letters", "32010105089000A1", time.Time{}, "", ErrNIKDigits},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := ParseNIK(tt.nik, today)
+			if err != tt.err {
+				t.Fatalf("ParseNIK() error = %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+
+			if !parsed.BirthDate.Equal(tt.birthDate) {
+				t.Errorf("BirthDate = %s, want %s", parsed.BirthDate.Format("2006-01-02"), tt.birthDate.Format("2006-01-02"))
+			}
+			if parsed.Gender != tt.gender {
+				t.Errorf("Gender = %s, want %s", parsed.Gender, tt.gender)
+			}
+			if parsed.ProvinceCode != 32 || parsed.RegencyCode != 3201 {
+				t.Errorf("region codes = %d %d", parsed.ProvinceCode, parsed.RegencyCode)
+			}
+		})
+	}
+}
+
+func TestParseNIKAge(t *testing.T) {
+	// the two digit year keeps a birth date within 100 years of today, the bounds catch a today before 1900+yy
+	tests := []struct {
+		name  string
+		today time.Time
+		err   error
+	}{
+		{"within the bound", time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC), nil},
+		{"before the birth date", time.Date(1926, 1, 1, 0, 0, 0, 0, time.UTC), ErrNIKBirthDate},
+		{"zero today", time.Time{}, ErrNIKBirthDate},
+		{"oldest birth date of the century rule", time.Date(2090, 8, 4, 0, 0, 0, 0, time.UTC), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseNIK("3201010508900001", tt.today)
+			if err != tt.err {
+				t.Errorf("ParseNIK() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestNormalizeGender(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"L", GENDER_MALE},
+		{"l", GENDER_MALE},
+		{"LK", GENDER_MALE},
+		{"Laki-laki", GENDER_MALE},
+		{"LAKI - LAKI", GENDER_MALE},
+		{"  laki   laki ", GENDER_MALE},
+		{"Laki", GENDER_MALE},
+		{"Pria", GENDER_MALE},
+		{"M", GENDER_MALE},
+		{"male", GENDER_MALE},
+		{"P", GENDER_FEMALE},
+		{"PR", GENDER_FEMALE},
+		{"Perempuan", GENDER_FEMALE},
+		{"wanita", GENDER_FEMALE},
+		{"W", GENDER_FEMALE},
+		{"F", GENDER_FEMALE},
+		{"Female", GENDER_FEMALE},
+		{"", ""},
+		{"-", ""},
+		{"X", ""},
+		{"Lelaki", ""},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeGender(tt.value); got != tt.want {
+			t.Errorf("NormalizeGender(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
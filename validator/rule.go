@@ -0,0 +1,152 @@
+/*
+ * Created on 18/10/26 05.37
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package validator
+
+import "strings"
+
+// the kinds of check a rule runs on the value of its fields
+const KIND_REQUIRED = "required"
+const KIND_LENGTH = "length"
+const KIND_CHARSET = "charset"
+const KIND_PATTERN = "pattern"
+const KIND_NIK = "nik"
+
+// the codes of a failed rule
+const CODE_REQUIRED = "REQUIRED"
+const CODE_INVALID_LENGTH = "INVALID_LENGTH"
+const CODE_INVALID_FORMAT = "INVALID_FORMAT"
+const CODE_NIK_BIRTH_DATE = "NIK_BIRTH_DATE"
+const CODE_NIK_GENDER_MISMATCH = "NIK_GENDER_MISMATCH"
+
+// Rule is a named check on one or more participant fields. Min and Max bound the number of characters
+// of a length rule and 0 leaves that side open, Chars are the characters a charset rule allows ignoring case
+// and Pattern is the regular expression a pattern rule matches. A nik rule checks the birth date and gender
+// encoded in the NIK. An empty Message is built from the field label
+type Rule struct {
+	Name    string   `json:"name"`
+	Kind    string   `json:"kind"`
+	Fields  []string `json:"fields"`
+	Min     int      `json:"min"`
+	Max     int      `json:"max"`
+	Chars   string   `json:"chars"`
+	Pattern string   `json:"pattern"`
+	Message string   `json:"message"`
+	Enabled bool     `json:"enabled"`
+}
+
+// Labels are the names of the participant fields used in the messages
+var Labels = map[string]string{
+	"name":                "Nama",
+	"nik":                 "NIK",
+	"gender":              "Jenis Kelamin",
+	"phone":               "No Handphone",
+	"address":             "Alamat",
+	"rt":                  "RT",
+	"rw":                  "RW",
+	"provinsi":            "Provinsi",
+	"kota":                "Kota/Kabupaten",
+	"kecamatan":           "Kecamatan",
+	"kelurahan":           "Kelurahan",
+	"kode_pos":            "Kode POS",
+	"residence_address":   "Alamat Domisili",
+	"residence_rt":        "RT Domisili",
+	"residence_rw":        "RW Domisili",
+	"residence_provinsi":  "Provinsi Domisili",
+	"residence_kota":      "Kota/Kabupaten Domisili",
+	"residence_kecamatan": "Kecamatan Domisili",
+	"residence_kelurahan": "Kelurahan Domisili",
+	"residence_kode_pos":  "Kode POS Domisili",
+}
+
+const DIGITS = "0123456789"
+
+// RULE_NIK is the rule of the birth date and gender encoded in the NIK, the caller also checks the region codes
+// of the NIK while it is enabled
+const RULE_NIK = "nik_semantic"
+
+// DefaultRules are the rules of every program that has not changed them
+func DefaultRules() []*Rule {
+	return []*Rule{
+		{
+			Name: "required",
+			Kind: KIND_REQUIRED,
+			Fields: []string{"name", "nik", "gender", "phone", "address", "rt", "rw", "provinsi", "kota", "kecamatan", "kelurahan",
+				"kode_pos", "residence_address", "residence_rt", "residence_rw", "residence_provinsi", "residence_kota",
+				"residence_kecamatan", "residence_kelurahan", "residence_kode_pos"},
+			Enabled: true,
+		},
+		{Name: "name_charset", Kind: KIND_CHARSET, Fields: []string{"name"}, Chars: "abcdefghijklmnopqrstuvwxyz '.,-", Enabled: true},
+		{Name: "nik_digits", Kind: KIND_CHARSET, Fields: []string{"nik"}, Chars: DIGITS, Message: "NIK terdapat karakter atau simbol karakter", Enabled: true},
+		{Name: "nik_length", Kind: KIND_LENGTH, Fields: []string{"nik"}, Min: 16, Max: 16, Enabled: true},
+		{Name: RULE_NIK, Kind: KIND_NIK, Fields: []string{"nik"}, Enabled: true},
+		{Name: "phone_length", Kind: KIND_LENGTH, Fields: []string{"phone"}, Min: 10, Max: 13, Enabled: true},
+		{Name: "phone_charset", Kind: KIND_CHARSET, Fields: []string{"phone"}, Chars: DIGITS + "+", Enabled: true},
+		{Name: "rt_length", Kind: KIND_LENGTH, Fields: []string{"rt", "residence_rt"}, Max: 3, Enabled: true},
+		{Name: "rw_length", Kind: KIND_LENGTH, Fields: []string{"rw", "residence_rw"}, Max: 3, Enabled: true},
+	}
+}
+
+// Merge applies the overrides on the rules by name, an override of a rule that does not exist adds it.
+// Every attribute an override leaves out is kept from the rule it replaces, the chars and pattern only while
+// the kind stays the same. Enabled is always taken from the override
+func Merge(rules []*Rule, overrides []*Rule) []*Rule {
+	merged := make([]*Rule, len(rules))
+	copy(merged, rules)
+
+	for _, override := range overrides {
+		rule := *override
+		found := false
+		for i, current := range merged {
+			if current.Name != rule.Name {
+				continue
+			}
+			if rule.Kind == "" {
+				rule.Kind = current.Kind
+			}
+			if len(rule.Fields) == 0 {
+				rule.Fields = current.Fields
+			}
+			if rule.Min == 0 {
+				rule.Min = current.Min
+			}
+			if rule.Max == 0 {
+				rule.Max = current.Max
+			}
+			if rule.Kind == current.Kind && rule.Chars == "" {
+				rule.Chars = current.Chars
+			}
+			if rule.Kind == current.Kind && rule.Pattern == "" {
+				rule.Pattern = current.Pattern
+			}
+			if rule.Message == "" {
+				rule.Message = current.Message
+			}
+			merged[i] = &rule
+			found = true
+			break
+		}
+		if !found {
+			merged = append(merged, &rule)
+		}
+	}
+	return merged
+}
+
+func label(field string) string {
+	if l, ok := Labels[field]; ok {
+		return l
+	}
+	return strings.ReplaceAll(field, "_", " ")
+}
+
+func IsKind(kind string) bool {
+	switch kind {
+	case KIND_REQUIRED, KIND_LENGTH, KIND_CHARSET, KIND_PATTERN, KIND_NIK:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+/*
+ * Created on 18/10/26 05.37
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package validator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Issue is a rule a field failed
+type Issue struct {
+	Rule    string `json:"rule"`
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Validator runs its enabled rules in order, a field stops at its first failed rule
+// so an empty NIK is only reported as empty
+type Validator struct {
+	rules    []*Rule
+	patterns map[string]*regexp.Regexp
+}
+
+// New keeps the enabled rules, an unknown kind or a pattern that does not compile is an error
+func New(rules []*Rule) (*Validator, error) {
+	v := &Validator{patterns: make(map[string]*regexp.Regexp)}
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+
+		if !IsKind(rule.Kind) {
+			return nil, fmt.Errorf("jenis aturan tidak dikenal: %s", rule.Kind)
+		}
+
+		if rule.Kind == KIND_PATTERN {
+			pattern, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("pola aturan %s tidak valid: %s", rule.Name, rule.Pattern)
+			}
+			v.patterns[rule.Name] = pattern
+		}
+
+		v.rules = append(v.rules, rule)
+	}
+	return v, nil
+}
+
+// Has tells whether the rule is enabled
+func (v *Validator) Has(name string) bool {
+	for _, rule := range v.rules {
+		if rule.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Rules are the enabled rules of the validator
+func (v *Validator) Rules() []*Rule {
+	return v.rules
+}
+
+// Validate checks the values by field, a field missing from values is empty
+func (v *Validator) Validate(values map[string]string) []*Issue {
+	var issues []*Issue
+	failed := make(map[string]bool)
+	for _, rule := range v.rules {
+		for _, field := range rule.Fields {
+			if failed[field] {
+				continue
+			}
+
+			if code, message := v.check(rule, field, values); code != "" {
+				if rule.Message != "" {
+					message = rule.Message
+				}
+				issues = append(issues, &Issue{Rule: rule.Name, Field: field, Code: code, Message: message})
+				failed[field] = true
+			}
+		}
+	}
+	return issues
+}
+
+// check returns the code and message of a failed rule, an empty value only fails the required rules
+func (v *Validator) check(rule *Rule, field string, values map[string]string) (string, string) {
+	value := values[field]
+	if rule.Kind == KIND_REQUIRED {
+		if strings.TrimSpace(value) == "" {
+			return CODE_REQUIRED, fmt.Sprintf("%s Kosong", label(field))
+		}
+		return "", ""
+	}
+
+	if value == "" {
+		return "", ""
+	}
+
+	switch rule.Kind {
+	case KIND_LENGTH:
+		length := utf8.RuneCountInString(strings.TrimSpace(value))
+		if rule.Min > 0 && rule.Min == rule.Max && length != rule.Min {
+			return CODE_INVALID_LENGTH, fmt.Sprintf("%s tidak %d digit", label(field), rule.Min)
+		}
+		if rule.Max > 0 && length > rule.Max {
+			return CODE_INVALID_LENGTH, fmt.Sprintf("%s lebih dari %d digit", label(field), rule.Max)
+		}
+		if rule.Min > 0 && length < rule.Min {
+			return CODE_INVALID_LENGTH, fmt.Sprintf("%s kurang dari %d digit", label(field), rule.Min)
+		}
+	case KIND_CHARSET:
+		chars := strings.ToLower(rule.Chars)
+		for _, char := range value {
+			if !strings.ContainsRune(chars, unicode.ToLower(char)) {
+				return CODE_INVALID_FORMAT, fmt.Sprintf("%s Tidak Sesuai Format", label(field))
+			}
+		}
+	case KIND_PATTERN:
+		if !v.patterns[rule.Name].MatchString(value) {
+			return CODE_INVALID_FORMAT, fmt.Sprintf("%s Tidak Sesuai Format", label(field))
+		}
+	case KIND_NIK:
+		return checkNIK(values, value)
+	}
+	return "", ""
+}
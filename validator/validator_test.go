@@ -0,0 +1,235 @@
+/*
+ * Created on 21/10/26 13.15
+ *
+ * Copyright (c) 2023 Abdul Ghani Abbasi
+ */
+
+package validator
+
+import (
+	"reflect"
+	"testing"
+)
+
+// validValues is a participant that passes every default rule
+func validValues() map[string]string {
+	values := make(map[string]string)
+	for field := range Labels {
+		values[field] = "Bogor"
+	}
+	values["name"] = "Siti Aisyah"
+	values["nik"] = "3201016902960001"
+	values["gender"] = "Perempuan"
+	values["phone"] = "081234567890"
+	values["rt"], values["rw"], values["residence_rt"], values["residence_rw"] = "001", "02", "3", "004"
+	return values
+}
+
+func TestValidate(t *testing.T) {
+	v, err := New(DefaultRules())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		change map[string]string
+		want   []*Issue
+	}{
+		{"valid", nil, nil},
+		{
+			name:   "empty fields are only reported as empty",
+			change: map[string]string{"nik": "", "phone": " "},
+			want: []*Issue{
+				{Rule: "required", Field: "nik", Code: CODE_REQUIRED, Message: "NIK Kosong"},
+				{Rule: "required", Field: "phone", Code: CODE_REQUIRED, Message: "No Handphone Kosong"},
+			},
+		},
+		{
+			name:   "charset ignores case",
+			change: map[string]string{"name": "Siti A'isyah, S.Pd-"},
+		},
+		{
+			name:   "charset",
+			change: map[string]string{"name": "Siti_2"},
+			want:   []*Issue{{Rule: "name_charset", Field: "name", Code: CODE_INVALID_FORMAT, Message: "Nama Tidak Sesuai Format"}},
+		},
+		{
+			name:   "custom message",
+			change: map[string]string{"nik": "32010169029600A1"},
+			want:   []*Issue{{Rule: "nik_digits", Field: "nik", Code: CODE_INVALID_FORMAT, Message: "NIK terdapat karakter atau simbol karakter"}},
+		},
+		{
+			name:   "exact length",
+			change: map[string]string{"nik": "320101690296"},
+			want:   []*Issue{{Rule: "nik_length", Field: "nik", Code: CODE_INVALID_LENGTH, Message: "NIK tidak 16 digit"}},
+		},
+		{
+			name:   "length bounds",
+			change: map[string]string{"phone": "08123", "residence_rw": "0001"},
+			want: []*Issue{
+				{Rule: "phone_length", Field: "phone", Code: CODE_INVALID_LENGTH, Message: "No Handphone kurang dari 10 digit"},
+				{Rule: "rw_length", Field: "residence_rw", Code: CODE_INVALID_LENGTH, Message: "RW Domisili lebih dari 3 digit"},
+			},
+		},
+		{
+			name:   "nik birth date",
+			change: map[string]string{"nik": "3201013102900001"},
+			want:   []*Issue{{Rule: RULE_NIK, Field: "nik", Code: CODE_NIK_BIRTH_DATE, Message: ErrNIKBirthDate.Error()}},
+		},
+		{
+			name:   "nik gender",
+			change: map[string]string{"gender": "laki-laki"},
+			want:   []*Issue{{Rule: RULE_NIK, Field: "nik", Code: CODE_NIK_GENDER_MISMATCH, Message: "Jenis Kelamin tidak sesuai dengan NIK"}},
+		},
+		{
+			name:   "unknown gender is not compared",
+			change: map[string]string{"gender": "-"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values := validValues()
+			for field, value := range tt.change {
+				values[field] = value
+			}
+
+			got := v.Validate(values)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate() = %v, want %v", issues(got), issues(tt.want))
+			}
+		})
+	}
+}
+
+func issues(list []*Issue) []Issue {
+	var values []Issue
+	for _, issue := range list {
+		values = append(values, *issue)
+	}
+	return values
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   []*Rule
+		has     []string
+		wantErr bool
+	}{
+		{
+			name:  "disabled rules are dropped",
+			rules: []*Rule{{Name: "a", Kind: KIND_REQUIRED, Enabled: true}, {Name: "b", Kind: KIND_REQUIRED}},
+			has:   []string{"a"},
+		},
+		{
+			name:    "unknown kind",
+			rules:   []*Rule{{Name: "a", Kind: "email", Enabled: true}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid pattern",
+			rules:   []*Rule{{Name: "a", Kind: KIND_PATTERN, Pattern: "[0-9", Enabled: true}},
+			wantErr: true,
+		},
+		{
+			name:  "disabled rules are not checked",
+			rules: []*Rule{{Name: "a", Kind: "email"}, {Name: "b", Kind: KIND_PATTERN, Pattern: "[0-9"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := New(tt.rules)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if len(v.Rules()) != len(tt.has) {
+				t.Errorf("Rules() = %d rules, want %d", len(v.Rules()), len(tt.has))
+			}
+			for _, name := range tt.has {
+				if !v.Has(name) {
+					t.Errorf("Has(%q) = false", name)
+				}
+			}
+		})
+	}
+}
+
+func TestPatternRule(t *testing.T) {
+	v, err := New([]*Rule{{Name: "kode_pos", Kind: KIND_PATTERN, Fields: []string{"kode_pos"}, Pattern: `^[0-9]{5}$`, Enabled: true}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tests := []struct {
+		value string
+		code  string
+	}{
+		{"16111", ""},
+		{"", ""},
+		{"1611", CODE_INVALID_FORMAT},
+		{"16111a", CODE_INVALID_FORMAT},
+	}
+
+	for _, tt := range tests {
+		got := v.Validate(map[string]string{"kode_pos": tt.value})
+		code := ""
+		if len(got) > 0 {
+			code = got[0].Code
+		}
+		if code != tt.code {
+			t.Errorf("Validate(%q) code = %q, want %q", tt.value, code, tt.code)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	rules := []*Rule{
+		{Name: "required", Kind: KIND_REQUIRED, Fields: []string{"name", "nik"}, Enabled: true},
+		{Name: "phone_length", Kind: KIND_LENGTH, Fields: []string{"phone"}, Min: 10, Max: 13, Enabled: true},
+		{Name: "rt_length", Kind: KIND_LENGTH, Fields: []string{"rt"}, Max: 3, Enabled: true},
+		{Name: "nik_digits", Kind: KIND_CHARSET, Fields: []string{"nik"}, Chars: DIGITS, Message: "NIK terdapat karakter atau simbol karakter", Enabled: true},
+		{Name: "name_charset", Kind: KIND_CHARSET, Fields: []string{"name"}, Chars: "abc", Enabled: true},
+	}
+	overrides := []*Rule{
+		{Name: "phone_length", Max: 15, Enabled: true},
+		{Name: "rt_length", Min: 1, Max: 4, Enabled: true},
+		{Name: "required", Fields: []string{"name"}, Enabled: false},
+		{Name: "nik_digits", Enabled: false},
+		{Name: "name_charset", Kind: KIND_PATTERN, Pattern: `^[A-Z]+$`, Enabled: true},
+		{Name: "kode_pos", Kind: KIND_PATTERN, Fields: []string{"kode_pos"}, Pattern: `^\d{5}$`, Enabled: true},
+	}
+
+	got := Merge(rules, overrides)
+	want := []*Rule{
+		{Name: "required", Kind: KIND_REQUIRED, Fields: []string{"name"}, Enabled: false},
+		{Name: "phone_length", Kind: KIND_LENGTH, Fields: []string{"phone"}, Min: 10, Max: 15, Enabled: true},
+		{Name: "rt_length", Kind: KIND_LENGTH, Fields: []string{"rt"}, Min: 1, Max: 4, Enabled: true},
+		{Name: "nik_digits", Kind: KIND_CHARSET, Fields: []string{"nik"}, Chars: DIGITS, Message: "NIK terdapat karakter atau simbol karakter", Enabled: false},
+		{Name: "name_charset", Kind: KIND_PATTERN, Fields: []string{"name"}, Pattern: `^[A-Z]+$`, Enabled: true},
+		{Name: "kode_pos", Kind: KIND_PATTERN, Fields: []string{"kode_pos"}, Pattern: `^\d{5}$`, Enabled: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge() = %v, want %v", got, want)
+	}
+
+	// the rules merged into are left as they were
+	if rules[1].Min != 10 || !rules[0].Enabled {
+		t.Errorf("Merge() changed its input: %v", rules)
+	}
+}
+
+func TestLabel(t *testing.T) {
+	if got := label("residence_kota"); got != "Kota/Kabupaten Domisili" {
+		t.Errorf("label() = %q", got)
+	}
+	if got := label("nama_ibu"); got != "nama ibu" {
+		t.Errorf("label() = %q", got)
+	}
+}